@@ -0,0 +1,630 @@
+package fractal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"mandelbrot-fractal/proto"
+)
+
+// DefaultSlaveTimeout is the max time to wait for every tile calculated by a
+// slave node. The tiles of deep zooms take long even in healthy slave nodes,
+// the slave nodes that hang are left behind when the view changes anyway.
+const DefaultSlaveTimeout = time.Minute
+
+// ErrFrameIncomplete is returned by RenderContext when some tiles of the frame
+// were not calculated by any node, which happens when every slave node fails
+// and the master node has no threads.
+var ErrFrameIncomplete = errors.New("frame incomplete, no node calculated some of its tiles")
+
+// MaxResponseSize is the largest region response accepted from a slave node,
+// the iteration data of a region takes up to 12 bytes per pixel.
+const MaxResponseSize = 256 << 20
+
+// ResponseFormat selects what the slave nodes return for the regions they
+// calculate.
+type ResponseFormat int32
+
+const (
+	// RGBResponse returns the pixels colored by the slave node.
+	RGBResponse ResponseFormat = iota
+	// IterationsResponse returns the iteration counts and the final |z| of
+	// the pixels, which are colored by the master node.
+	IterationsResponse
+)
+
+// Backoff of the slave nodes that fail: they are sent regions again after
+// MinSlaveBackoff, doubling the wait on every consecutive failure up to
+// MaxSlaveBackoff.
+const (
+	MinSlaveBackoff = time.Second
+	MaxSlaveBackoff = 30 * time.Second
+)
+
+// SlaveState is the health of a slave node. A slave node that fails to
+// calculate a region or times out is unhealthy and isn't sent regions until
+// its retry time, when the connection is attempted again.
+type SlaveState struct {
+	Healthy   bool
+	Failures  int       // Consecutive failures
+	RetryAt   time.Time // Time from which an unhealthy slave node is sent regions again
+	LastError error
+}
+
+// available reports whether the slave node can be sent regions at time now.
+func (s SlaveState) available(now time.Time) bool {
+	return s.Healthy || !now.Before(s.RetryAt)
+}
+
+func (s *SlaveState) fail(err error, now time.Time) {
+	s.Healthy = false
+	s.Failures++
+	backoff := MaxSlaveBackoff
+	if s.Failures <= 16 {
+		backoff = MinSlaveBackoff << uint(s.Failures-1)
+	}
+	if backoff > MaxSlaveBackoff {
+		backoff = MaxSlaveBackoff
+	}
+	s.RetryAt = now.Add(backoff)
+	s.LastError = err
+}
+
+func (s SlaveState) String() string {
+	if s.Healthy {
+		return "healthy"
+	}
+	if wait := time.Until(s.RetryAt); wait > 0 {
+		return fmt.Sprintf("unhealthy, retry in %s", wait.Round(time.Second))
+	}
+	return "unhealthy, retrying"
+}
+
+// Cluster renders frames split in tiles that the slave nodes and the threads
+// of the master node take on demand until all of them are calculated. The
+// tiles of the slave nodes that fail are calculated by the healthy nodes in
+// the same frame.
+type Cluster struct {
+	Renderer                 *Renderer      // Renderer of the master node
+	SlavePort                int32          // Port of the slave nodes listed without one
+	SlaveTimeout             time.Duration  // Max time to wait for every tile calculated by a slave node
+	ResponseFormat           ResponseFormat // Payload requested to the slave nodes
+	KeepIterations           bool           // Keep the iteration data of every sample of the frames to recolor them
+	SlavesIPs                []string
+	SlavesPorts              []int32
+	SlavesCores              []int32 // Cores of each slave node, 0 when unknown (slave nodes listed up-front)
+	SlavesClients            []proto.MandelbrotSlaveNodeClient
+	SlavesStates             []SlaveState // Health of each slave node
+	SlavesCount              int32
+	OnTile                   func(tile Region) // Called, when not nil, with every tile drawn into the frame being rendered, from the goroutine that calculated it
+	NodesTiles               []int             // Tiles calculated in the last frame by each slave node and the master node (last value in the array)
+	NodesThreadsProcessTimes [][]time.Duration // Thread processing times of all slave nodes
+	DistributedWaitGroup     sync.WaitGroup
+	FrameProcessTime         time.Duration
+	Generation               uint64           // Frame being rendered, increased with every frame
+	SkippedIterations        int              // Iterations skipped by the series approximation in the last frame
+	Iterations               *IterationBuffer // Iteration counts of the last frame
+	slavesConnections        []*grpc.ClientConn
+	slavesRegistered         []bool // The slave node registered itself, it leaves the cluster when its heartbeats stop
+	slavesFailed             []bool // The slave node failed in the current frame, it takes no more tiles
+	slavesKeepFrame          []bool // The slave node keeps the palette and the reference orbit of the current frame
+	registrations            map[string]registration
+	registrationsMutex       sync.Mutex
+	slavesMutex              sync.Mutex // Guards the slave nodes state updated by the regions calculated in parallel
+	dataRegions              []Region   // Regions of the last frame with iteration counts
+	coloredRegions           []Region   // Regions of the last frame colored by slave nodes that don't return iteration counts
+}
+
+// NewCluster connects to every slave node, listed as IP or IP:port. Without
+// slaves all the frames are rendered by the local renderer, until some slave
+// node registers itself (see ListenForSlaves).
+func NewCluster(maxLocalThreads int32, slavesIPs []string, slavePort int32) (*Cluster, error) {
+	c := &Cluster{Renderer: NewRenderer(maxLocalThreads), SlavePort: slavePort, SlaveTimeout: DefaultSlaveTimeout, ResponseFormat: IterationsResponse, KeepIterations: true}
+	c.NodesTiles = make([]int, 1) // tiles calculated by each slave and the master (last value in array)
+	c.registrations = make(map[string]registration)
+
+	for _, address := range slavesIPs {
+		host, port := address, slavePort
+		if h, p, err := net.SplitHostPort(address); err == nil {
+			number, err := strconv.ParseInt(p, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid slave node address %q", address)
+			}
+			host, port = h, int32(number)
+		}
+		if err := c.addSlave(host, port, 0, false); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// addSlave connects to a slave node and adds it to the cluster.
+func (c *Cluster) addSlave(ip string, port int32, cores int32, registered bool) error {
+	// The connection is established in the background, the slave nodes that
+	// aren't reachable fail their regions and are retried later
+	address := net.JoinHostPort(ip, strconv.Itoa(int(port)))
+	conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(MaxResponseSize)))
+	if err != nil {
+		return fmt.Errorf("cannot connect to slave node at %s: %v", address, err)
+	}
+
+	c.SlavesIPs = append(c.SlavesIPs, ip)
+	c.SlavesPorts = append(c.SlavesPorts, port)
+	c.SlavesCores = append(c.SlavesCores, cores)
+	c.SlavesClients = append(c.SlavesClients, proto.NewMandelbrotSlaveNodeClient(conn))
+	c.SlavesStates = append(c.SlavesStates, SlaveState{Healthy: true})
+	c.NodesThreadsProcessTimes = append(c.NodesThreadsProcessTimes, nil)
+	c.slavesConnections = append(c.slavesConnections, conn)
+	c.slavesRegistered = append(c.slavesRegistered, registered)
+	c.slavesFailed = append(c.slavesFailed, false)
+	c.slavesKeepFrame = append(c.slavesKeepFrame, false)
+
+	// The master node keeps the last value of the nodes arrays
+	master := c.SlavesCount
+	c.NodesTiles = append(c.NodesTiles[:master], 0, c.NodesTiles[master])
+	c.SlavesCount++
+	return nil
+}
+
+// removeSlave disconnects the slave node i and removes it from the cluster.
+func (c *Cluster) removeSlave(i int32) {
+	c.slavesConnections[i].Close()
+
+	c.SlavesIPs = append(c.SlavesIPs[:i], c.SlavesIPs[i+1:]...)
+	c.SlavesPorts = append(c.SlavesPorts[:i], c.SlavesPorts[i+1:]...)
+	c.SlavesCores = append(c.SlavesCores[:i], c.SlavesCores[i+1:]...)
+	c.SlavesClients = append(c.SlavesClients[:i], c.SlavesClients[i+1:]...)
+	c.SlavesStates = append(c.SlavesStates[:i], c.SlavesStates[i+1:]...)
+	c.NodesThreadsProcessTimes = append(c.NodesThreadsProcessTimes[:i], c.NodesThreadsProcessTimes[i+1:]...)
+	c.slavesConnections = append(c.slavesConnections[:i], c.slavesConnections[i+1:]...)
+	c.slavesRegistered = append(c.slavesRegistered[:i], c.slavesRegistered[i+1:]...)
+	c.slavesFailed = append(c.slavesFailed[:i], c.slavesFailed[i+1:]...)
+	c.slavesKeepFrame = append(c.slavesKeepFrame[:i], c.slavesKeepFrame[i+1:]...)
+	c.NodesTiles = append(c.NodesTiles[:i], c.NodesTiles[i+1:]...)
+	c.SlavesCount--
+}
+
+// SlaveAddress returns the address of the slave node i.
+func (c *Cluster) SlaveAddress(i int32) string {
+	return net.JoinHostPort(c.SlavesIPs[i], strconv.Itoa(int(c.SlavesPorts[i])))
+}
+
+// Render calculates the whole viewport into img. It returns
+// ErrFrameIncomplete when some tiles could not be calculated.
+func (c *Cluster) Render(viewport Viewport, img *image.RGBA) error {
+	return c.RenderContext(context.Background(), viewport, img)
+}
+
+// RenderContext is Render stopping when ctx is done, for instance because
+// the viewport changed: the calculations in flight are cancelled in the
+// master node and in the slave nodes, and ctx.Err() is returned. The frame
+// is left partially rendered and cannot be recolored, like the frames with
+// tiles that no node could calculate, which return ErrFrameIncomplete.
+func (c *Cluster) RenderContext(ctx context.Context, viewport Viewport, img *image.RGBA) error {
+	start := time.Now()
+	c.Generation++
+
+	// The reference orbit is calculated once and shared with all the nodes
+	if viewport.UsesPerturbation() && viewport.ReferenceOrbit == nil {
+		viewport.ReferenceOrbit = NewReferenceOrbit(viewport)
+	}
+
+	c.SkippedIterations = 0
+	if viewport.UsesPerturbation() {
+		c.SkippedIterations = viewport.ReferenceOrbit.SkippedIterations
+	}
+
+	// The iteration data of every sample takes 12 bytes, it is only kept to
+	// recolor the frame. The histogram coloring needs the data of the whole
+	// frame anyway.
+	if !c.KeepIterations && viewport.Coloring != HistogramColoring {
+		c.Iterations = nil
+	} else {
+		if c.Iterations == nil || c.Iterations.Region != viewport.Bounds() || c.Iterations.Samples != viewport.Samples() {
+			c.Iterations = NewIterationBuffer(viewport.Bounds(), viewport.Samples())
+		}
+		c.Iterations.MaxIterations = viewport.MaxIterations
+		c.Iterations.EstimatedDistance = viewport.Coloring.UsesDistance()
+	}
+
+	// The slave nodes join and leave the cluster between frames
+	c.updateMembership()
+
+	c.dataRegions = c.dataRegions[:0]
+	c.coloredRegions = c.coloredRegions[:0]
+	for i := range c.NodesTiles {
+		c.NodesTiles[i] = 0
+	}
+	for i := range c.slavesFailed {
+		c.slavesFailed[i] = false
+		c.slavesKeepFrame[i] = false
+	}
+
+	// The slave nodes and the threads of the master node take tiles until all
+	// of them are calculated, the unhealthy slave nodes waiting to be retried
+	// take none. The slave nodes are handed their first tiles before the
+	// master node starts, so every slave node is tried in every frame.
+	queue := newTileQueue(viewport.Bounds().Tiles(TileSize))
+	now := time.Now()
+	for slaveIndex := int32(0); slaveIndex < c.SlavesCount; slaveIndex++ {
+		if !c.SlavesStates[slaveIndex].available(now) {
+			continue
+		}
+		if !c.SlavesStates[slaveIndex].Healthy {
+			// Reconnect right away instead of waiting for the gRPC backoff
+			c.slavesConnections[slaveIndex].ResetConnectBackoff()
+		}
+		for i := 0; i < SlaveRequestsInFlight; i++ {
+			if tile, ok := queue.takeWaiting(); ok {
+				c.DistributedWaitGroup.Add(1)
+				go c.calculateTilesInSlaveNode(ctx, slaveIndex, viewport, img, queue, tile)
+			}
+		}
+	}
+
+	master := c.SlavesCount
+	c.Renderer.calculateTiles(ctx, newFrame(viewport), img, c.Iterations, queue, func(tile Region) {
+		c.addRegion(master, tile, true)
+	})
+
+	// Wait for all distributed calculations
+	c.DistributedWaitGroup.Wait()
+
+	if !queue.complete() {
+		// Cancelled or left without nodes, the iteration counts of the
+		// frame are incomplete
+		c.Iterations = nil
+		c.FrameProcessTime = time.Since(start)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return ErrFrameIncomplete
+	}
+
+	if viewport.Coloring == HistogramColoring {
+		c.recolorHistogram(viewport, img)
+	}
+
+	c.FrameProcessTime = time.Since(start)
+	return nil
+}
+
+// calculateTilesInSlaveNode calculates tile, taken from the queue, in a
+// slave node along with the tiles waiting in the queue, up to
+// SlaveTilesPerRequest, and keeps taking tiles until every tile is calculated
+// or the slave node fails. Then the tiles that the slave node didn't return
+// are put back for another node.
+func (c *Cluster) calculateTilesInSlaveNode(ctx context.Context, slaveIndex int32, viewport Viewport, img *image.RGBA, queue *tileQueue, tile Region) {
+	defer c.DistributedWaitGroup.Done()
+
+	for {
+		tiles := []Region{tile}
+		for len(tiles) < SlaveTilesPerRequest {
+			next, ok := queue.takeWaiting()
+			if !ok {
+				break
+			}
+			tiles = append(tiles, next)
+		}
+
+		left := make(map[Region]bool, len(tiles))
+		for _, tile := range tiles {
+			left[tile] = true
+		}
+		calculated := func(tile Region) {
+			delete(left, tile)
+			queue.done()
+		}
+		if c.slaveFailed(slaveIndex) || c.CalculateTilesInSlaveNode(ctx, slaveIndex, viewport, img, tiles, calculated) != nil {
+			for tile := range left {
+				queue.retry(tile)
+			}
+			return
+		}
+
+		var ok bool
+		if tile, ok = queue.take(); !ok {
+			return
+		}
+	}
+}
+
+// slaveFailed reports whether the slave node failed in the current frame.
+func (c *Cluster) slaveFailed(slaveIndex int32) bool {
+	c.slavesMutex.Lock()
+	defer c.slavesMutex.Unlock()
+	return c.slavesFailed[slaveIndex]
+}
+
+// addRegion records a region calculated in the frame by a node (the master
+// node is SlavesCount), with iteration counts or colored by a slave node.
+func (c *Cluster) addRegion(node int32, region Region, withData bool) {
+	c.slavesMutex.Lock()
+	c.NodesTiles[node]++
+	if withData {
+		c.dataRegions = append(c.dataRegions, region)
+	} else {
+		c.coloredRegions = append(c.coloredRegions, region)
+	}
+	c.slavesMutex.Unlock()
+
+	if c.OnTile != nil {
+		c.OnTile(region)
+	}
+}
+
+// Recolor paints the last frame rendered into img with the colors of
+// viewport without iterating again. Only the coloring settings and the max
+// iterations (lower or equal) of viewport may differ from the ones of the
+// frame. It returns false, leaving img untouched, when the stored iteration
+// counts cannot be recolored or some slave node returned colors instead of
+// iteration counts; the frame must be rendered again then.
+func (c *Cluster) Recolor(viewport Viewport, img *image.RGBA) bool {
+	if c.Iterations == nil || !c.Iterations.CanRecolor(viewport) {
+		return false
+	}
+	if len(c.coloredRegions) > 0 {
+		return false
+	}
+	var histogram *Histogram
+	if viewport.Coloring == HistogramColoring {
+		histogram = c.Iterations.Histogram(viewport.Bounds(), viewport.MaxIterations)
+	}
+	c.Iterations.Recolor(viewport, histogram, img, viewport.Bounds())
+	return true
+}
+
+// recolorHistogram colors the regions of the last frame with the merged
+// distribution of the iteration counts of all the regions. The regions of the
+// slave nodes that returned colors keep the distribution of their region.
+func (c *Cluster) recolorHistogram(viewport Viewport, img *image.RGBA) {
+	histogram := NewHistogram(viewport.MaxIterations)
+	for _, region := range c.dataRegions {
+		c.Iterations.addToHistogram(histogram, region, viewport.MaxIterations)
+	}
+
+	for _, region := range c.dataRegions {
+		c.Iterations.Recolor(viewport, histogram, img, region)
+	}
+}
+
+// CalculateRegionInSlaveNode calculates a region of the frame in a slave
+// node. When the slave node fails or times out it is marked unhealthy and the
+// error is returned, so the region can be calculated by another node. When
+// ctx is done the request is cancelled and ctx.Err() is returned.
+func (c *Cluster) CalculateRegionInSlaveNode(ctx context.Context, slaveIndex int32, viewport Viewport, img *image.RGBA, region Region) error {
+	requestCtx, cancel := context.WithTimeout(ctx, c.SlaveTimeout)
+	defer cancel()
+
+	// Send the job to the slave node with the region to calculate. Only the
+	// slave nodes with tile streaming keep the frame, the others are sent it
+	// with every region
+	request := newCalculateRegionRequest(viewport, slaveIndex, region, c.responseFormat(), false)
+	request.Generation = c.Generation
+	response, err := c.SlavesClients[slaveIndex].CalculateRegion(requestCtx, request)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return c.slaveFailure(slaveIndex, err)
+	}
+
+	c.drawSlaveRegion(slaveIndex, viewport, img, region, response)
+	return nil
+}
+
+// CalculateTilesInSlaveNode calculates tiles of the frame in a slave node,
+// which streams every tile back as soon as it is calculated; calculated is
+// called after drawing every tile into img. When the slave node fails or
+// doesn't send a tile in time it is marked unhealthy and the error is
+// returned, so the tiles not calculated yet can be calculated by another
+// node. The palette and the reference orbit of the frame are sent until the
+// slave node keeps them. Slave nodes of older versions, without tile
+// streaming, calculate the tiles one by one. When ctx is done the request is
+// cancelled and ctx.Err() is returned.
+func (c *Cluster) CalculateTilesInSlaveNode(ctx context.Context, slaveIndex int32, viewport Viewport, img *image.RGBA, tiles []Region, calculated func(tile Region)) error {
+	requestCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The timeout starts again with every tile received
+	timeout := time.AfterFunc(c.SlaveTimeout, cancel)
+	defer timeout.Stop()
+
+	cachedFrame := c.slaveKeepsFrame(slaveIndex)
+	request := newCalculateRegionRequest(viewport, slaveIndex, Region{}, c.responseFormat(), cachedFrame)
+	request.Tiles = encodeTiles(tiles)
+	request.Generation = c.Generation
+	stream, err := c.SlavesClients[slaveIndex].CalculateTiles(requestCtx, request)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		return c.slaveFailure(slaveIndex, err)
+	}
+
+	left := make(map[Region]bool, len(tiles))
+	for _, tile := range tiles {
+		left[tile] = true
+	}
+	for len(left) > 0 {
+		response, err := stream.Recv()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if status.Code(err) == codes.Unimplemented && len(left) == len(tiles) {
+			return c.calculateTilesOneByOne(ctx, slaveIndex, viewport, img, tiles, calculated)
+		}
+		if status.Code(err) == codes.FailedPrecondition && cachedFrame && len(left) == len(tiles) {
+			// The slave node forgot the frame, send it again
+			c.setSlaveKeepsFrame(slaveIndex, false)
+			cancel()
+			return c.CalculateTilesInSlaveNode(ctx, slaveIndex, viewport, img, tiles, calculated)
+		}
+		if err == io.EOF {
+			// A stream that ends before every tile fails like a broken one
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return c.slaveFailure(slaveIndex, err)
+		}
+
+		tile := Region{XStart: response.GetXStart(), YStart: response.GetYStart(), XEnd: response.GetXEnd(), YEnd: response.GetYEnd()}
+		if !left[tile] {
+			return c.slaveFailure(slaveIndex, fmt.Errorf("unexpected tile %+v", tile))
+		}
+		timeout.Reset(c.SlaveTimeout)
+		delete(left, tile)
+		if response.GetKeepsFrame() && !cachedFrame {
+			c.setSlaveKeepsFrame(slaveIndex, true)
+		}
+		c.drawSlaveRegion(slaveIndex, viewport, img, tile, response)
+		calculated(tile)
+	}
+	return nil
+}
+
+// calculateTilesOneByOne calculates tiles of the frame in a slave node
+// without tile streaming, one request per tile.
+func (c *Cluster) calculateTilesOneByOne(ctx context.Context, slaveIndex int32, viewport Viewport, img *image.RGBA, tiles []Region, calculated func(tile Region)) error {
+	for _, tile := range tiles {
+		if err := c.CalculateRegionInSlaveNode(ctx, slaveIndex, viewport, img, tile); err != nil {
+			return err
+		}
+		calculated(tile)
+	}
+	return nil
+}
+
+// slaveKeepsFrame reports whether the slave node keeps the palette and the
+// reference orbit of the current frame.
+func (c *Cluster) slaveKeepsFrame(slaveIndex int32) bool {
+	c.slavesMutex.Lock()
+	defer c.slavesMutex.Unlock()
+	return c.slavesKeepFrame[slaveIndex]
+}
+
+func (c *Cluster) setSlaveKeepsFrame(slaveIndex int32, keeps bool) {
+	c.slavesMutex.Lock()
+	defer c.slavesMutex.Unlock()
+	c.slavesKeepFrame[slaveIndex] = keeps
+}
+
+// slaveFailure marks the slave node unhealthy for the rest of the frame and
+// returns the error of the request that failed.
+func (c *Cluster) slaveFailure(slaveIndex int32, err error) error {
+	c.slavesMutex.Lock()
+	if !c.slavesFailed[slaveIndex] {
+		// The requests in flight fail together, they count as one failure
+		c.SlavesStates[slaveIndex].fail(err, time.Now())
+		c.slavesFailed[slaveIndex] = true
+	}
+	c.slavesMutex.Unlock()
+	return fmt.Errorf("slave node %d (%s) failed: %v", slaveIndex, c.SlaveAddress(slaveIndex), err)
+}
+
+// responseFormat returns the payload requested to the slave nodes in the
+// current frame, the colors when the iteration data isn't kept.
+func (c *Cluster) responseFormat() ResponseFormat {
+	if c.Iterations == nil {
+		return RGBResponse
+	}
+	return c.ResponseFormat
+}
+
+// drawSlaveRegion updates the frame with a region calculated in a slave node.
+func (c *Cluster) drawSlaveRegion(slaveIndex int32, viewport Viewport, img *image.RGBA, region Region, response *proto.CalculateRegionResponse) {
+	// Slaves that don't support iteration data return colors
+	iterations := response.GetIterations()
+	if len(iterations) > 0 {
+		DecodeIterations(iterations, response.GetModulus(), response.GetDistance(), c.Iterations, region)
+		c.Iterations.Recolor(viewport, nil, img, region)
+	} else {
+		DecodeRGB(response.GetRGBPixels(), img, region)
+	}
+
+	// Store slave node threads processing times (used only to show node stats)
+	slaveThreadsProcessTimesInt64 := response.GetThreadsProcessTimes()
+	threadsProcessTimes := make([]time.Duration, len(slaveThreadsProcessTimesInt64))
+	for e := range slaveThreadsProcessTimesInt64 {
+		threadsProcessTimes[e] = time.Duration(slaveThreadsProcessTimesInt64[e]) * time.Nanosecond
+	}
+
+	c.slavesMutex.Lock()
+	c.NodesThreadsProcessTimes[slaveIndex] = threadsProcessTimes
+	if !c.slavesFailed[slaveIndex] {
+		c.SlavesStates[slaveIndex] = SlaveState{Healthy: true}
+	}
+	c.slavesMutex.Unlock()
+
+	c.addRegion(slaveIndex, region, len(iterations) > 0)
+}
+
+// newCalculateRegionRequest returns the request sent to a slave node to
+// calculate a region of the viewport, returned in the given format. With
+// cachedFrame the palette and the reference orbit, kept by the slave node
+// from an earlier request of the frame, are left out.
+func newCalculateRegionRequest(viewport Viewport, index int32, region Region, format ResponseFormat, cachedFrame bool) *proto.CalculateRegionRequest {
+	magnificationFactor, _ := viewport.MagnificationFactor.Float64()
+	panX, _ := viewport.PanX.Float64()
+	panY, _ := viewport.PanY.Float64()
+	request := &proto.CalculateRegionRequest{
+		MagnificationFactor:        magnificationFactor,
+		MaxIterations:              viewport.MaxIterations,
+		PanX:                       panX,
+		PanY:                       panY,
+		Index:                      index,
+		Width:                      viewport.Width,
+		Height:                     viewport.Height,
+		XStart:                     region.XStart,
+		YStart:                     region.YStart,
+		XEnd:                       region.XEnd,
+		YEnd:                       region.YEnd,
+		Coloring:                   int32(viewport.Coloring),
+		BailoutRadius:              viewport.Bailout(),
+		PreciseMagnificationFactor: viewport.MagnificationFactor.Text('p', 0),
+		PrecisePanX:                viewport.PanX.Text('p', 0),
+		PrecisePanY:                viewport.PanY.Text('p', 0),
+		Precision:                  uint32(maxPrecision(0, viewport.MagnificationFactor, viewport.PanX, viewport.PanY)),
+		DeepZoom:                   int32(viewport.DeepZoom),
+		Formula:                    viewport.IteratedFormula().String(),
+		ResponseFormat:             int32(format),
+		Supersampling:              viewport.Supersampling,
+		CachedFrame:                cachedFrame,
+	}
+	if cachedFrame {
+		return request
+	}
+	if viewport.Palette != nil {
+		palette, _ := viewport.Palette.MarshalJSON()
+		request.Palette = string(palette)
+	}
+	if orbit := viewport.ReferenceOrbit; orbit != nil {
+		request.ReferenceOrbitReal = orbit.Real
+		request.ReferenceOrbitImaginary = orbit.Imaginary
+		request.SkippedIterations = int32(orbit.SkippedIterations)
+		request.SeriesCoefficients = []float64{real(orbit.SeriesA), imag(orbit.SeriesA), real(orbit.SeriesB), imag(orbit.SeriesB), real(orbit.SeriesC), imag(orbit.SeriesC)}
+	}
+	return request
+}
+
+// encodeTiles packs tiles as XStart, YStart, XEnd and YEnd quadruples, the
+// format of the tiles requested to the slave nodes.
+func encodeTiles(tiles []Region) []int32 {
+	encoded := make([]int32, 0, 4*len(tiles))
+	for _, tile := range tiles {
+		encoded = append(encoded, tile.XStart, tile.YStart, tile.XEnd, tile.YEnd)
+	}
+	return encoded
+}
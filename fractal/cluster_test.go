@@ -0,0 +1,314 @@
+package fractal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"mandelbrot-fractal/proto"
+)
+
+// startTestSlave serves a slave node on a free port of 127.0.0.1.
+func startTestSlave(t *testing.T) (int32, *grpc.Server) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	proto.RegisterMandelbrotSlaveNodeServer(server, &SlaveNodeServer{MaxLocalThreads: 4})
+	go server.Serve(lis)
+	return int32(lis.Addr().(*net.TCPAddr).Port), server
+}
+
+func TestClusterSurvivesFailedSlaves(t *testing.T) {
+	port, server := startTestSlave(t)
+	defer server.Stop()
+
+	// Nothing listens on 127.0.0.2
+	cluster, err := NewCluster(4, []string{"127.0.0.1", "127.0.0.2"}, port)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	viewport := Viewport{Width: 160, Height: 90, MagnificationFactor: NewFloat(50), MaxIterations: 100, PanX: NewFloat(2), PanY: NewFloat(0.9), Coloring: SmoothColoring}
+	want := image.NewRGBA(viewport.Bounds().Rect())
+	NewRenderer(4).CalculateRegionLocally(viewport, want, nil, viewport.Bounds())
+
+	render := func(frame string) {
+		img := image.NewRGBA(viewport.Bounds().Rect())
+		cluster.Render(viewport, img)
+		if !bytes.Equal(img.Pix, want.Pix) {
+			t.Errorf("%s differs from the frame rendered locally", frame)
+		}
+	}
+
+	// The region of the failed slave is calculated by the healthy one
+	render("frame with a failed slave")
+	if !cluster.SlavesStates[0].Healthy {
+		t.Errorf("slave 0 unhealthy: %v", cluster.SlavesStates[0].LastError)
+	}
+	if state := cluster.SlavesStates[1]; state.Healthy || state.Failures != 1 || !state.RetryAt.After(time.Now()) {
+		t.Errorf("slave 1 state %+v, want unhealthy waiting to retry", state)
+	}
+	render("frame with an unhealthy slave")
+	if tiles := cluster.NodesTiles[0] + cluster.NodesTiles[1] + cluster.NodesTiles[2]; tiles != len(viewport.Bounds().Tiles(TileSize)) || cluster.NodesTiles[1] != 0 {
+		t.Errorf("tiles calculated by the nodes %v, want all of them but by the unhealthy slave", cluster.NodesTiles)
+	}
+
+	// Without healthy slaves the master calculates the whole frame
+	server.Stop()
+	render("frame without healthy slaves")
+	if cluster.SlavesStates[0].Healthy {
+		t.Errorf("slave 0 healthy after stopping it")
+	}
+}
+
+// unarySlaveNodeServer is a slave node of an older version, without tile
+// streaming.
+type unarySlaveNodeServer struct {
+	proto.UnimplementedMandelbrotSlaveNodeServer
+	server *SlaveNodeServer
+}
+
+func (s *unarySlaveNodeServer) CalculateRegion(ctx context.Context, request *proto.CalculateRegionRequest) (*proto.CalculateRegionResponse, error) {
+	return s.server.CalculateRegion(ctx, request)
+}
+
+func TestClusterStreamsTiles(t *testing.T) {
+	port, server := startTestSlave(t)
+	defer server.Stop()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unaryServer := grpc.NewServer()
+	proto.RegisterMandelbrotSlaveNodeServer(unaryServer, &unarySlaveNodeServer{server: &SlaveNodeServer{MaxLocalThreads: 4}})
+	go unaryServer.Serve(lis)
+	defer unaryServer.Stop()
+
+	cluster, err := NewCluster(4, []string{fmt.Sprintf("127.0.0.1:%d", port), lis.Addr().String()}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mutex sync.Mutex
+	arrived := make(map[Region]int)
+	cluster.OnTile = func(tile Region) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		arrived[tile]++
+	}
+
+	viewport := Viewport{Width: 300, Height: 200, MagnificationFactor: NewFloat(100), MaxIterations: 200, PanX: NewFloat(2), PanY: NewFloat(1), Coloring: HistogramColoring}
+	want := image.NewRGBA(viewport.Bounds().Rect())
+	NewRenderer(4).CalculateRegionLocally(viewport, want, nil, viewport.Bounds())
+	img := image.NewRGBA(viewport.Bounds().Rect())
+	cluster.Render(viewport, img)
+
+	if !bytes.Equal(img.Pix, want.Pix) {
+		t.Errorf("frame differs from the frame rendered locally")
+	}
+	for i, state := range cluster.SlavesStates {
+		if !state.Healthy {
+			t.Errorf("slave %d unhealthy: %v", i, state.LastError)
+		}
+	}
+	tiles := viewport.Bounds().Tiles(TileSize)
+	if len(arrived) != len(tiles) {
+		t.Errorf("%d tiles arrived, want %d", len(arrived), len(tiles))
+	}
+	for tile, n := range arrived {
+		if n != 1 {
+			t.Errorf("tile %+v arrived %d times", tile, n)
+		}
+	}
+}
+
+// forgetfulSlaveNodeServer is a slave node that counts the requests sending
+// the frame and forgets the frames kept at its third request.
+type forgetfulSlaveNodeServer struct {
+	*SlaveNodeServer
+	mutex      sync.Mutex
+	requests   int
+	withFrames int
+}
+
+func (s *forgetfulSlaveNodeServer) CalculateTiles(request *proto.CalculateRegionRequest, stream proto.MandelbrotSlaveNode_CalculateTilesServer) error {
+	s.mutex.Lock()
+	s.requests++
+	if !request.GetCachedFrame() {
+		s.withFrames++
+	}
+	forget := s.requests == 3
+	s.mutex.Unlock()
+
+	if forget {
+		s.framesMutex.Lock()
+		for _, frame := range s.frames {
+			frame.kept = false
+		}
+		s.framesMutex.Unlock()
+	}
+	return s.SlaveNodeServer.CalculateTiles(request, stream)
+}
+
+func TestClusterSendsFrameOnce(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slave := &forgetfulSlaveNodeServer{SlaveNodeServer: &SlaveNodeServer{MaxLocalThreads: 4}}
+	server := grpc.NewServer()
+	proto.RegisterMandelbrotSlaveNodeServer(server, slave)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	// Without threads in the master node the slave node calculates every tile
+	cluster, err := NewCluster(0, []string{lis.Addr().String()}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cluster.ResponseFormat = RGBResponse
+
+	palette := &Palette{Name: "test", Stops: []ColorStop{{0.25, color.RGBA{A: 255}}, {0.75, color.RGBA{R: 255, G: 255, B: 255, A: 255}}}}
+	viewport := Viewport{Width: 320, Height: 240, MagnificationFactor: NewFloat(1e20), MaxIterations: 2000, Coloring: SmoothColoring, Palette: palette}
+	x, _ := ParseFloat("-0.743643887037158704752191506114774")
+	y, _ := ParseFloat("0.131825904205311970493132056385139")
+	viewport.SetCenter(x, y)
+	viewport.ReferenceOrbit = NewReferenceOrbit(viewport)
+	want := image.NewRGBA(viewport.Bounds().Rect())
+	NewRenderer(4).CalculateRegionLocally(viewport, want, nil, viewport.Bounds())
+	img := image.NewRGBA(viewport.Bounds().Rect())
+	if err := cluster.Render(viewport, img); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(img.Pix, want.Pix) {
+		t.Errorf("frame differs from the frame rendered locally")
+	}
+	if !cluster.SlavesStates[0].Healthy {
+		t.Errorf("slave unhealthy: %v", cluster.SlavesStates[0].LastError)
+	}
+	// The requests in flight before the first response send the frame, and
+	// the ones after the slave node forgets it
+	slave.mutex.Lock()
+	defer slave.mutex.Unlock()
+	if max := 2 * SlaveRequestsInFlight; slave.withFrames > max {
+		t.Errorf("%d of %d requests sent the frame, want at most %d", slave.withFrames, slave.requests, max)
+	}
+}
+
+func TestClusterWithoutIterations(t *testing.T) {
+	port, server := startTestSlave(t)
+	defer server.Stop()
+
+	cluster, err := NewCluster(4, []string{"127.0.0.1"}, port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cluster.KeepIterations = false
+
+	for _, coloring := range []ColoringMode{SmoothColoring, HistogramColoring} {
+		viewport := Viewport{Width: 300, Height: 200, MagnificationFactor: NewFloat(100), MaxIterations: 200, PanX: NewFloat(2), PanY: NewFloat(1), Coloring: coloring, Supersampling: 2}
+		want := image.NewRGBA(viewport.Bounds().Rect())
+		NewRenderer(4).CalculateRegionLocally(viewport, want, nil, viewport.Bounds())
+		img := image.NewRGBA(viewport.Bounds().Rect())
+		if err := cluster.Render(viewport, img); err != nil {
+			t.Fatal(err)
+		}
+
+		if !bytes.Equal(img.Pix, want.Pix) {
+			t.Errorf("%v: frame differs from the frame rendered locally", coloring)
+		}
+		// The histogram coloring needs the iteration data of the frame
+		if kept := cluster.Iterations != nil; kept != (coloring == HistogramColoring) {
+			t.Errorf("%v: iteration data kept %v", coloring, kept)
+		}
+	}
+	if cluster.NodesTiles[0] == 0 {
+		t.Errorf("no tile calculated by the slave node")
+	}
+}
+
+func TestRenderCancelled(t *testing.T) {
+	port, server := startTestSlave(t)
+	defer server.Stop()
+
+	cluster, err := NewCluster(4, []string{"127.0.0.1"}, port)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The frame is cancelled when its first tile arrives
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cluster.OnTile = func(tile Region) { cancel() }
+	viewport := Viewport{Width: 300, Height: 200, MagnificationFactor: NewFloat(100), MaxIterations: 2000, PanX: NewFloat(2), PanY: NewFloat(1)}
+	img := image.NewRGBA(viewport.Bounds().Rect())
+	if err := cluster.RenderContext(ctx, viewport, img); err != context.Canceled {
+		t.Fatalf("cancelled frame returned %v, want %v", err, context.Canceled)
+	}
+	if cluster.Iterations != nil || cluster.Recolor(viewport, img) {
+		t.Errorf("cancelled frame can be recolored")
+	}
+	if state := cluster.SlavesStates[0]; !state.Healthy || state.Failures != 0 {
+		t.Errorf("slave state %+v after cancelling the frame, want healthy", state)
+	}
+
+	cluster.OnTile = nil
+	generation := cluster.Generation
+	if err := cluster.RenderContext(context.Background(), viewport, img); err != nil {
+		t.Fatal(err)
+	}
+	want := image.NewRGBA(viewport.Bounds().Rect())
+	NewRenderer(4).CalculateRegionLocally(viewport, want, nil, viewport.Bounds())
+	if !bytes.Equal(img.Pix, want.Pix) {
+		t.Errorf("frame after a cancelled one differs from the frame rendered locally")
+	}
+	if cluster.Generation != generation+1 {
+		t.Errorf("generation %d after generation %d, want %d", cluster.Generation, generation, generation+1)
+	}
+}
+
+func TestRenderIncomplete(t *testing.T) {
+	// Nothing listens on 127.0.0.2 and the master node has no threads
+	cluster, err := NewCluster(0, []string{"127.0.0.2"}, 50051)
+	if err != nil {
+		t.Fatal(err)
+	}
+	viewport := Viewport{Width: 160, Height: 90, MagnificationFactor: NewFloat(50), MaxIterations: 100, PanX: NewFloat(2), PanY: NewFloat(0.9)}
+	img := image.NewRGBA(viewport.Bounds().Rect())
+	if err := cluster.Render(viewport, img); err != ErrFrameIncomplete {
+		t.Fatalf("frame without nodes returned %v, want %v", err, ErrFrameIncomplete)
+	}
+	if cluster.Iterations != nil || cluster.Recolor(viewport, img) {
+		t.Errorf("incomplete frame can be recolored")
+	}
+}
+
+func TestSlaveBackoff(t *testing.T) {
+	var state SlaveState
+	now := time.Now()
+	for i, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		state.fail(nil, now)
+		if wait := state.RetryAt.Sub(now); wait != want {
+			t.Errorf("failure %d retries after %v, want %v", i+1, wait, want)
+		}
+	}
+	for i := 0; i < 100; i++ {
+		state.fail(nil, now)
+	}
+	if wait := state.RetryAt.Sub(now); wait != MaxSlaveBackoff {
+		t.Errorf("retry after %v, want %v", wait, MaxSlaveBackoff)
+	}
+	if state.available(now) || !state.available(state.RetryAt) {
+		t.Errorf("unhealthy slave available before its retry time or not after it")
+	}
+}
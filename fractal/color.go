@@ -0,0 +1,141 @@
+package fractal
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// ColoringMode selects how the iteration count of a pixel is turned into a
+// color.
+type ColoringMode int32
+
+const (
+	// IterationColoring maps the integer iteration count to a hue, which
+	// produces visible bands.
+	IterationColoring ColoringMode = iota
+	// SmoothColoring maps a normalized (fractional) iteration count to a hue.
+	SmoothColoring
+	// HistogramColoring maps the fraction of pixels of the frame that escaped
+	// before a pixel to a hue, which spreads the palette evenly.
+	HistogramColoring
+	// DistanceColoring maps the estimated distance to the set to a hue.
+	DistanceColoring
+	// BoundaryColoring paints the pixels closer to the set than
+	// BoundaryDistance as part of it, so thin filaments are drawn at pixel
+	// width, and the rest of pixels as SmoothColoring.
+	BoundaryColoring
+)
+
+var coloringModeNames = map[ColoringMode]string{
+	IterationColoring: "iterations",
+	SmoothColoring:    "smooth",
+	HistogramColoring: "histogram",
+	DistanceColoring:  "distance",
+	BoundaryColoring:  "boundary",
+}
+
+func (c ColoringMode) String() string {
+	if name, ok := coloringModeNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("ColoringMode(%d)", int32(c))
+}
+
+// UsesDistance reports whether the coloring mode needs the distance
+// estimation, only available for the Differentiable formulas. Without it the
+// pixels are colored as SmoothColoring.
+func (c ColoringMode) UsesDistance() bool {
+	return c == DistanceColoring || c == BoundaryColoring
+}
+
+// ParseColoringMode returns the coloring mode with the given name.
+func ParseColoringMode(name string) (ColoringMode, error) {
+	for mode, modeName := range coloringModeNames {
+		if modeName == name {
+			return mode, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown coloring mode %q", name)
+}
+
+// InsideColor is the color of the points that belong to the set.
+var InsideColor = color.RGBA{0, 0, 0, 255} // black
+
+// IterationColor maps the iteration at which a point escaped to a color of
+// the hue bar.
+func IterationColor(i float64, maxIterations float64) color.RGBA {
+	hue := math.Mod(i*360/maxIterations, 360)
+	if hue < 0 {
+		hue += 360
+	}
+	colorHSV := colorful.Hsv(hue, 0.98, 0.922) // hue bar color (Hsv)
+	return color.RGBA{uint8(colorHSV.R * 255), uint8(colorHSV.G * 255), uint8(colorHSV.B * 255), 255}
+}
+
+// PixelColor returns the color of a pixel whose orbit escaped at iteration i
+// with a final |z| of modulus at a distance to the set of distance pixels,
+// for a formula of the given degree. The points that reached the max
+// iterations of the viewport belong to the set.
+//
+// The histogram coloring needs the distribution of the whole frame, see
+// Histogram.PixelColor, the pixels are colored as smooth here.
+func (v Viewport) PixelColor(i float64, modulus float64, distance float64, degree float64) color.RGBA {
+	if i >= v.MaxIterations {
+		return InsideColor
+	}
+	if v.Coloring.UsesDistance() && distance > 0 {
+		if v.Coloring == BoundaryColoring && distance < BoundaryDistance {
+			return InsideColor
+		}
+		if v.Coloring == DistanceColoring {
+			return v.gradientColor(math.Log2(1+distance) / DistanceOctaves * v.MaxIterations)
+		}
+	}
+	if v.Coloring != IterationColoring {
+		i = SmoothIterations(i, modulus*modulus, degree)
+	}
+	return v.gradientColor(i)
+}
+
+// gradientColor returns the color of the palette of the viewport for the
+// (normalized) iteration count i.
+func (v Viewport) gradientColor(i float64) color.RGBA {
+	if v.Palette == nil {
+		return IterationColor(i, v.MaxIterations)
+	}
+	return v.Palette.IterationColor(i, v.MaxIterations)
+}
+
+// SmoothIterations returns the normalized iteration count of a point that
+// escaped at iteration i with a squared modulus of modulusSquared, removing
+// the bands between consecutive integer iterations (log-log smoothing). The
+// degree is the power of z in the iterated formula.
+func SmoothIterations(i float64, modulusSquared float64, degree float64) float64 {
+	smooth := i + 1 - math.Log(math.Log(modulusSquared)/2)/math.Log(degree)
+	if smooth < 0 || math.IsNaN(smooth) {
+		return 0
+	}
+	return smooth
+}
+
+// colorAverage accumulates the colors of the samples of a pixel.
+type colorAverage struct {
+	red, green, blue uint32
+	samples          uint32
+}
+
+func (a *colorAverage) add(c color.RGBA) {
+	a.red += uint32(c.R)
+	a.green += uint32(c.G)
+	a.blue += uint32(c.B)
+	a.samples++
+}
+
+// color returns the average of the colors added, rounded to the nearest.
+func (a *colorAverage) color() color.RGBA {
+	half := a.samples / 2
+	return color.RGBA{uint8((a.red + half) / a.samples), uint8((a.green + half) / a.samples), uint8((a.blue + half) / a.samples), 255}
+}
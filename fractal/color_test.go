@@ -0,0 +1,52 @@
+package fractal
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSmoothIterationsContinuous(t *testing.T) {
+	// Along the real axis beyond the cusp of the cardioid the points escape
+	// sooner the farther they are, the smoothed count decreases steadily
+	// across the iterations where the escape iteration drops by one
+	const bailout = 1000
+	last, lastIteration := math.Inf(1), math.Inf(1)
+	drops := 0
+	for x := 0.3; x <= 1; x += 1e-4 {
+		i, modulusSquared := Iterate(x, 0, 1000, bailout)
+		smooth := SmoothIterations(i, modulusSquared, 2)
+		if smooth > last+1e-9 {
+			t.Fatalf("smoothed count of %v grows from %v to %v", x, last, smooth)
+		}
+		if !math.IsInf(last, 1) && last-smooth > 0.05 {
+			t.Fatalf("smoothed count of %v jumps from %v to %v at iteration %v", x, last, smooth, i)
+		}
+		if i < lastIteration && !math.IsInf(lastIteration, 1) {
+			drops++
+		}
+		last, lastIteration = smooth, i
+	}
+	if drops == 0 {
+		t.Errorf("no change of escape iteration tested")
+	}
+}
+
+func TestSmoothIterationsClamped(t *testing.T) {
+	tests := []struct {
+		name           string
+		i              float64
+		modulusSquared float64
+	}{
+		{"huge modulus at the first iteration", 0, 1e300},
+		{"zero modulus", 3, 0},
+		{"negative modulus", 3, -1},
+		{"NaN modulus", 3, math.NaN()},
+		{"NaN iteration", math.NaN(), 100},
+	}
+
+	for _, test := range tests {
+		if got := SmoothIterations(test.i, test.modulusSquared, 2); got != 0 {
+			t.Errorf("%s: SmoothIterations(%v, %v) = %v, want 0", test.name, test.i, test.modulusSquared, got)
+		}
+	}
+}
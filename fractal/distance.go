@@ -0,0 +1,88 @@
+package fractal
+
+import "math"
+
+// BoundaryDistance is the distance to the set, in pixels, under which the
+// boundary coloring paints a pixel as part of the set.
+const BoundaryDistance = 1
+
+// DistanceOctaves is the number of doublings of the distance to the set
+// spanned by the palette in the distance coloring.
+const DistanceOctaves = 12
+
+// Differentiable is implemented by the formulas that can track the
+// derivative of the orbit, needed by the distance estimation. The derivative
+// of the first value of the orbit is 1.
+type Differentiable interface {
+	// Derivative returns the derivative of the next value of the orbit from
+	// the current value z and its derivative dz.
+	Derivative(zr float64, zi float64, dzr float64, dzi float64) (float64, float64)
+}
+
+// Derivative of z² + c with respect to c: 2·z·dz + 1.
+func (Mandelbrot) Derivative(zr float64, zi float64, dzr float64, dzi float64) (float64, float64) {
+	return 2*(zr*dzr-zi*dzi) + 1, 2 * (zr*dzi + zi*dzr)
+}
+
+// Derivative of z² + c with respect to the starting point: 2·z·dz.
+func (Julia) Derivative(zr float64, zi float64, dzr float64, dzi float64) (float64, float64) {
+	return 2 * (zr*dzr - zi*dzi), 2 * (zr*dzi + zi*dzr)
+}
+
+// Derivative of z^n + c with respect to c: n·z^(n-1)·dz + 1.
+func (m Multibrot) Derivative(zr float64, zi float64, dzr float64, dzi float64) (float64, float64) {
+	realComponent, imaginaryComponent := dzr*float64(m.Power), dzi*float64(m.Power)
+	for p := 1; p < m.Power; p++ {
+		realComponent, imaginaryComponent = realComponent*zr-imaginaryComponent*zi, realComponent*zi+imaginaryComponent*zr
+	}
+	return realComponent + 1, imaginaryComponent
+}
+
+// IterateDistance is the version of IterateFormula that also returns the
+// modulus of the derivative of the orbit at the iteration it escaped, 0 when
+// the formula isn't Differentiable.
+func IterateDistance(formula Formula, x float64, y float64, maxIterations float64, bailoutRadius float64) (float64, float64, float64) {
+	differentiable, ok := formula.(Differentiable)
+	if !ok {
+		i, modulusSquared := IterateFormula(formula, x, y, maxIterations, bailoutRadius)
+		return i, modulusSquared, 0
+	}
+
+	if _, ok := formula.(Mandelbrot); ok && InsideMainBulbs(x, y) {
+		return maxIterations, 0, 0
+	}
+
+	realComponent, imaginaryComponent, cr, ci := formula.Start(x, y)
+	derivativeReal, derivativeImaginary := 1.0, 0.0
+	bailoutSquared := bailoutRadius * bailoutRadius
+	periodicity := newPeriodicityCheck(realComponent, imaginaryComponent)
+	var modulusSquared float64
+
+	for i := float64(0); i < maxIterations; i++ {
+		derivativeReal, derivativeImaginary = differentiable.Derivative(realComponent, imaginaryComponent, derivativeReal, derivativeImaginary)
+		realComponent, imaginaryComponent = formula.Step(realComponent, imaginaryComponent, cr, ci)
+
+		modulusSquared = realComponent*realComponent + imaginaryComponent*imaginaryComponent
+		if modulusSquared > bailoutSquared {
+			return i, modulusSquared, math.Hypot(derivativeReal, derivativeImaginary)
+		}
+
+		if periodicity.cycled(realComponent, imaginaryComponent) {
+			return maxIterations, modulusSquared, 0
+		}
+	}
+
+	return maxIterations, modulusSquared, 0
+}
+
+// DistanceEstimate returns the estimated distance to the set, in units of
+// the complex plane, of a point whose orbit escaped with a squared modulus of
+// modulusSquared and a derivative of modulus derivative: 2·|z|·ln|z| / |dz|.
+// It returns 0 when there is no estimate.
+func DistanceEstimate(modulusSquared float64, derivative float64) float64 {
+	if derivative <= 0 || math.IsInf(derivative, 0) || math.IsNaN(derivative) {
+		return 0
+	}
+	modulus := math.Sqrt(modulusSquared)
+	return 2 * modulus * math.Log(modulus) / derivative
+}
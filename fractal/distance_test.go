@@ -0,0 +1,72 @@
+package fractal
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistanceEstimate(t *testing.T) {
+	// Points of the real axis outside [-2, 0.25] at a known distance of the set
+	tests := []struct {
+		x, distance float64
+	}{
+		{0.5, 0.25},
+		{1, 0.75},
+		{3, 2.75},
+		{-2.2, 0.2},
+		{-3, 1},
+	}
+
+	for _, test := range tests {
+		i, modulusSquared, derivative := IterateDistance(Mandelbrot{}, test.x, 0, 1000, 1000)
+		if i >= 1000 {
+			t.Fatalf("IterateDistance(%v, 0) didn't escape", test.x)
+		}
+		// The estimate is within a small factor of the actual distance
+		estimate := DistanceEstimate(modulusSquared, derivative)
+		if estimate < test.distance/2 || estimate > 4*test.distance {
+			t.Errorf("DistanceEstimate of (%v, 0) = %v, want within [%v, %v]", test.x, estimate, test.distance/2, 4*test.distance)
+		}
+	}
+
+	if _, _, derivative := IterateDistance(Mandelbrot{}, -1, 0, 1000, 1000); derivative != 0 {
+		t.Errorf("IterateDistance(-1, 0) derivative = %v for a point of the set, want 0", derivative)
+	}
+	if _, _, derivative := IterateDistance(BurningShip{}, 1, 1, 1000, 1000); derivative != 0 {
+		t.Errorf("IterateDistance(BurningShip) derivative = %v, want 0 without Derivative", derivative)
+	}
+}
+
+func TestDistanceDeepZoomMatchesFloat64(t *testing.T) {
+	viewport := Viewport{Width: 64, Height: 48, MagnificationFactor: NewFloat(1e6), MaxIterations: 1000, BailoutRadius: 1000}
+	viewport.SetCenter(NewFloat(-0.7436), NewFloat(0.1318))
+	orbit := NewReferenceOrbit(viewport)
+
+	for x := int32(0); x < viewport.Width; x += 5 {
+		for y := int32(0); y < viewport.Height; y += 5 {
+			cx, cy := viewport.Coordinates(x, y)
+			i, modulusSquared, derivative := IterateDistance(Mandelbrot{}, cx, cy, viewport.MaxIterations, viewport.Bailout())
+			if i >= viewport.MaxIterations {
+				continue
+			}
+			want := DistanceEstimate(modulusSquared, derivative)
+			if want < 0.1/1e6 {
+				// The orbits of the pixels touching the set are too chaotic to compare
+				continue
+			}
+
+			preciseX, preciseY := viewport.PreciseCoordinates(x, y)
+			_, modulusSquared, derivative = IteratePreciseDistance(preciseX, preciseY, viewport.MaxIterations, viewport.Bailout())
+			if got := DistanceEstimate(modulusSquared, derivative); math.Abs(got-want) > want*1e-2 {
+				t.Errorf("IteratePreciseDistance of pixel (%d, %d) estimates %v, want %v", x, y, got, want)
+			}
+
+			dx := (float64(x) - float64(viewport.Width)/2) / 1e6
+			dy := (float64(y) - float64(viewport.Height)/2) / 1e6
+			_, modulusSquared, derivative = IteratePerturbationDistance(orbit, dx, dy, viewport.MaxIterations, viewport.Bailout())
+			if got := DistanceEstimate(modulusSquared, derivative); math.Abs(got-want) > want*1e-2 {
+				t.Errorf("IteratePerturbationDistance of pixel (%d, %d) estimates %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package fractal
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Formula is the function iterated to calculate a fractal.
+type Formula interface {
+	// Start returns the first value of the orbit z and the constant c of the
+	// point (x, y) of the complex plane.
+	Start(x float64, y float64) (zr float64, zi float64, cr float64, ci float64)
+	// Step calculates the next value of the orbit from z and c.
+	Step(zr float64, zi float64, cr float64, ci float64) (float64, float64)
+	// Degree is the power of z in the formula, used by the smooth coloring.
+	Degree() float64
+	// String returns the formula in the format parsed by ParseFormula.
+	String() string
+}
+
+// Mandelbrot is the formula z² + c starting at z = c.
+type Mandelbrot struct{}
+
+func (Mandelbrot) Start(x float64, y float64) (float64, float64, float64, float64) {
+	return x, y, x, y
+}
+
+func (Mandelbrot) Step(zr float64, zi float64, cr float64, ci float64) (float64, float64) {
+	return zr*zr - zi*zi + cr, 2*zr*zi + ci
+}
+
+func (Mandelbrot) Degree() float64 { return 2 }
+
+func (Mandelbrot) String() string { return "mandelbrot" }
+
+// Julia is the formula z² + c for a fixed c, the orbit starts at the point.
+type Julia struct {
+	CReal      float64
+	CImaginary float64
+}
+
+func (j Julia) Start(x float64, y float64) (float64, float64, float64, float64) {
+	return x, y, j.CReal, j.CImaginary
+}
+
+func (Julia) Step(zr float64, zi float64, cr float64, ci float64) (float64, float64) {
+	return zr*zr - zi*zi + cr, 2*zr*zi + ci
+}
+
+func (Julia) Degree() float64 { return 2 }
+
+func (j Julia) String() string {
+	return "julia:" + strconv.FormatFloat(j.CReal, 'g', -1, 64) + "," + strconv.FormatFloat(j.CImaginary, 'g', -1, 64)
+}
+
+// BurningShip is the formula (|Re(z)| + i·|Im(z)|)² + c starting at z = c.
+type BurningShip struct{}
+
+func (BurningShip) Start(x float64, y float64) (float64, float64, float64, float64) {
+	return x, y, x, y
+}
+
+func (BurningShip) Step(zr float64, zi float64, cr float64, ci float64) (float64, float64) {
+	return zr*zr - zi*zi + cr, 2*math.Abs(zr*zi) + ci
+}
+
+func (BurningShip) Degree() float64 { return 2 }
+
+func (BurningShip) String() string { return "burningship" }
+
+// Tricorn is the formula conj(z)² + c starting at z = c.
+type Tricorn struct{}
+
+func (Tricorn) Start(x float64, y float64) (float64, float64, float64, float64) {
+	return x, y, x, y
+}
+
+func (Tricorn) Step(zr float64, zi float64, cr float64, ci float64) (float64, float64) {
+	return zr*zr - zi*zi + cr, -2*zr*zi + ci
+}
+
+func (Tricorn) Degree() float64 { return 2 }
+
+func (Tricorn) String() string { return "tricorn" }
+
+// Multibrot is the formula z^Power + c starting at z = c.
+type Multibrot struct {
+	Power int
+}
+
+func (Multibrot) Start(x float64, y float64) (float64, float64, float64, float64) {
+	return x, y, x, y
+}
+
+func (m Multibrot) Step(zr float64, zi float64, cr float64, ci float64) (float64, float64) {
+	realComponent, imaginaryComponent := zr, zi
+	for p := 1; p < m.Power; p++ {
+		realComponent, imaginaryComponent = realComponent*zr-imaginaryComponent*zi, realComponent*zi+imaginaryComponent*zr
+	}
+	return realComponent + cr, imaginaryComponent + ci
+}
+
+func (m Multibrot) Degree() float64 { return float64(m.Power) }
+
+func (m Multibrot) String() string { return "multibrot:" + strconv.Itoa(m.Power) }
+
+// ParseFormula returns the formula described by spec: "mandelbrot",
+// "julia:<c real>,<c imaginary>", "burningship", "tricorn" or
+// "multibrot:<power>".
+func ParseFormula(spec string) (Formula, error) {
+	name, params := spec, ""
+	if i := strings.Index(spec, ":"); i >= 0 {
+		name, params = spec[:i], spec[i+1:]
+	}
+
+	switch name {
+	case "mandelbrot":
+		return Mandelbrot{}, nil
+	case "burningship":
+		return BurningShip{}, nil
+	case "tricorn":
+		return Tricorn{}, nil
+	case "julia":
+		c := strings.Split(params, ",")
+		if len(c) != 2 {
+			return nil, fmt.Errorf("invalid formula %q, expected julia:<c real>,<c imaginary>", spec)
+		}
+		cr, err := strconv.ParseFloat(strings.TrimSpace(c[0]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid formula %q: %v", spec, err)
+		}
+		ci, err := strconv.ParseFloat(strings.TrimSpace(c[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid formula %q: %v", spec, err)
+		}
+		return Julia{CReal: cr, CImaginary: ci}, nil
+	case "multibrot":
+		power, err := strconv.Atoi(params)
+		if err != nil || power < 2 {
+			return nil, fmt.Errorf("invalid formula %q, expected multibrot:<power> with a power of 2 or more", spec)
+		}
+		return Multibrot{Power: power}, nil
+	}
+
+	return nil, fmt.Errorf("unknown formula %q", spec)
+}
+
+// IterateFormula is the version of Iterate for any formula. The main bulbs
+// test only applies to the Mandelbrot formula, the periodicity check to all.
+func IterateFormula(formula Formula, x float64, y float64, maxIterations float64, bailoutRadius float64) (float64, float64) {
+	if _, ok := formula.(Mandelbrot); ok && InsideMainBulbs(x, y) {
+		return maxIterations, 0
+	}
+
+	realComponent, imaginaryComponent, cr, ci := formula.Start(x, y)
+	bailoutSquared := bailoutRadius * bailoutRadius
+	periodicity := newPeriodicityCheck(realComponent, imaginaryComponent)
+	var modulusSquared float64
+
+	for i := float64(0); i < maxIterations; i++ {
+		realComponent, imaginaryComponent = formula.Step(realComponent, imaginaryComponent, cr, ci)
+
+		modulusSquared = realComponent*realComponent + imaginaryComponent*imaginaryComponent
+		if modulusSquared > bailoutSquared {
+			return i, modulusSquared
+		}
+
+		if periodicity.cycled(realComponent, imaginaryComponent) {
+			return maxIterations, modulusSquared
+		}
+	}
+
+	return maxIterations, modulusSquared
+}
@@ -0,0 +1,59 @@
+package fractal
+
+import "testing"
+
+func TestParseFormula(t *testing.T) {
+	formulas := []Formula{Mandelbrot{}, Julia{CReal: -0.8, CImaginary: 0.156}, BurningShip{}, Tricorn{}, Multibrot{Power: 3}}
+
+	for _, formula := range formulas {
+		parsed, err := ParseFormula(formula.String())
+		if err != nil {
+			t.Errorf("ParseFormula(%q) error: %v", formula.String(), err)
+		} else if parsed != formula {
+			t.Errorf("ParseFormula(%q) = %#v, want %#v", formula.String(), parsed, formula)
+		}
+	}
+
+	for _, spec := range []string{"", "julia", "julia:1", "multibrot:1", "multibrot:x", "newton"} {
+		if _, err := ParseFormula(spec); err == nil {
+			t.Errorf("ParseFormula(%q) didn't fail", spec)
+		}
+	}
+}
+
+func TestIterateFormula(t *testing.T) {
+	tests := []struct {
+		formula Formula
+		x, y    float64
+		inside  bool
+	}{
+		{Mandelbrot{}, -1, 0, true},
+		{Mandelbrot{}, 1, 0, false},
+		{Multibrot{Power: 3}, 0.3, 0, true},
+		{Multibrot{Power: 3}, 0.8, 0, false},
+		{Julia{CReal: 0, CImaginary: 0}, 0.9, 0.3, true}, // the unit disc
+		{Julia{CReal: 0, CImaginary: 0}, 0.9, 0.5, false},
+		{BurningShip{}, -1.75, 0, true},
+		{BurningShip{}, 1, 1, false},
+		{Tricorn{}, -0.2, 0, true},
+		{Tricorn{}, 1, 1, false},
+	}
+
+	for _, test := range tests {
+		i, _ := IterateFormula(test.formula, test.x, test.y, 1000, DefaultBailoutRadius)
+		if inside := i == 1000; inside != test.inside {
+			t.Errorf("%s: point (%v, %v) inside %v, want %v", test.formula, test.x, test.y, inside, test.inside)
+		}
+	}
+}
+
+func TestIterateFormulaMatchesIterate(t *testing.T) {
+	for x := -2.0; x <= 0.5; x += 0.1 {
+		for y := -1.2; y <= 1.2; y += 0.1 {
+			want, _ := Iterate(x, y, 200, DefaultBailoutRadius)
+			if got, _ := IterateFormula(Multibrot{Power: 2}, x, y, 200, DefaultBailoutRadius); got != want {
+				t.Errorf("multibrot:2 point (%v, %v) escaped at iteration %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
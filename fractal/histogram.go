@@ -0,0 +1,111 @@
+package fractal
+
+import (
+	"image/color"
+	"math"
+)
+
+// Histogram is the distribution of the iteration counts of the points that
+// escaped in a frame. The histogram coloring maps every pixel to a color by
+// the fraction of pixels that escaped before it, so the palette is spread
+// evenly even when most of the pixels escape within a few iterations.
+type Histogram struct {
+	Counts     []uint32 // Pixels that escaped at each iteration
+	Total      uint32   // Pixels that escaped
+	cumulative []uint32 // Pixels that escaped before each iteration, kept up to date by the counting
+}
+
+// NewHistogram returns an empty histogram of the iterations up to
+// maxIterations, with no iterations when it isn't positive.
+func NewHistogram(maxIterations float64) *Histogram {
+	bins := 0
+	if maxIterations > 0 {
+		bins = int(math.Ceil(maxIterations))
+	}
+	return &Histogram{Counts: make([]uint32, bins), cumulative: make([]uint32, bins)}
+}
+
+// Add counts a point that escaped at iteration i. It takes time proportional
+// to the iterations of the histogram, IterationBuffer.Histogram counts whole
+// regions at once.
+func (h *Histogram) Add(i float64) {
+	if !h.count(i) {
+		return
+	}
+	for k := int(i) + 1; k < len(h.cumulative); k++ {
+		h.cumulative[k]++
+	}
+}
+
+// count counts a point that escaped at iteration i without updating the
+// cumulative counts, it reports whether the iteration is within the
+// histogram.
+func (h *Histogram) count(i float64) bool {
+	if i < 0 || int(i) >= len(h.Counts) {
+		return false
+	}
+	h.Counts[int(i)]++
+	h.Total++
+	return true
+}
+
+// accumulate calculates the cumulative counts after counting many points.
+func (h *Histogram) accumulate() {
+	accumulated := uint32(0)
+	for k, count := range h.Counts {
+		h.cumulative[k] = accumulated
+		accumulated += count
+	}
+}
+
+// Position returns the fraction, within [0, 1], of the points that escaped
+// before the (fractional) iteration i. It doesn't modify the histogram, so
+// it can be called concurrently.
+func (h *Histogram) Position(i float64) float64 {
+	if h.Total == 0 {
+		return 0
+	}
+
+	k := int(math.Floor(i))
+	if k < 0 {
+		return 0
+	}
+	if k >= len(h.Counts) {
+		return 1
+	}
+	// The fractional part of smooth iteration counts interpolates within the bin
+	fraction := i - float64(k)
+	return (float64(h.cumulative[k]) + fraction*float64(h.Counts[k])) / float64(h.Total)
+}
+
+// PixelColor returns the color of a pixel in the histogram coloring, see
+// Viewport.PixelColor.
+func (h *Histogram) PixelColor(v Viewport, i float64, modulus float64, degree float64) color.RGBA {
+	if i >= v.MaxIterations {
+		return InsideColor
+	}
+	return v.gradientColor(h.Position(SmoothIterations(i, modulus*modulus, degree)) * v.MaxIterations)
+}
+
+// Histogram returns the distribution of the iteration counts of the samples
+// of the region for the given max iterations.
+func (b *IterationBuffer) Histogram(region Region, maxIterations float64) *Histogram {
+	histogram := NewHistogram(maxIterations)
+	b.addToHistogram(histogram, region, maxIterations)
+	return histogram
+}
+
+// addToHistogram counts the samples of the region that escaped before the
+// given max iterations into histogram.
+func (b *IterationBuffer) addToHistogram(histogram *Histogram, region Region, maxIterations float64) {
+	for y := region.YStart; y <= region.YEnd; y++ {
+		for x := region.XStart; x <= region.XEnd; x++ {
+			for sample := int32(0); sample < b.Samples; sample++ {
+				if i, _, _ := b.At(x, y, sample); i < maxIterations {
+					histogram.count(i)
+				}
+			}
+		}
+	}
+	histogram.accumulate()
+}
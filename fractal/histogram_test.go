@@ -0,0 +1,70 @@
+package fractal
+
+import (
+	"image"
+	"math"
+	"testing"
+)
+
+func TestHistogramPosition(t *testing.T) {
+	histogram := NewHistogram(10)
+	for _, i := range []float64{0, 3, 1, 9, 1, 3, 10, 3, -1} { // 10 and -1 are out of range
+		histogram.Add(i)
+	}
+
+	if histogram.Total != 7 {
+		t.Errorf("Total = %d, want 7", histogram.Total)
+	}
+
+	tests := []struct {
+		i    float64
+		want float64
+	}{
+		{-1, 0},
+		{0, 0},
+		{1, 1.0 / 7},
+		{1.5, 2.0 / 7},
+		{2, 3.0 / 7},
+		{3, 3.0 / 7},
+		{9, 6.0 / 7},
+		{12, 1},
+	}
+	for _, test := range tests {
+		if got := histogram.Position(test.i); got != test.want {
+			t.Errorf("Position(%v) = %v, want %v", test.i, got, test.want)
+		}
+	}
+}
+
+func TestNewHistogramWithoutIterations(t *testing.T) {
+	for _, maxIterations := range []float64{0, -5, math.NaN()} {
+		histogram := NewHistogram(maxIterations)
+		histogram.Add(0)
+		if len(histogram.Counts) != 0 || histogram.Position(1) != 0 {
+			t.Errorf("NewHistogram(%v) = %+v, want no iterations", maxIterations, histogram)
+		}
+	}
+}
+
+func TestHistogramColoringSpreadsPalette(t *testing.T) {
+	// Most of the pixels of the default view escape within a few iterations
+	viewport := Viewport{Width: 160, Height: 90, MagnificationFactor: NewFloat(50), MaxIterations: 500, PanX: NewFloat(2), PanY: NewFloat(0.9)}
+	iterations := NewIterationBuffer(viewport.Bounds(), viewport.Samples())
+	NewRenderer(4).CalculateRegionLocally(viewport, image.NewRGBA(viewport.Bounds().Rect()), iterations, viewport.Bounds())
+	histogram := iterations.Histogram(viewport.Bounds(), viewport.MaxIterations)
+
+	var below, above int
+	for _, i := range iterations.Iterations {
+		if float64(i) >= viewport.MaxIterations {
+			continue
+		}
+		if histogram.Position(float64(i)) < 0.5 {
+			below++
+		} else {
+			above++
+		}
+	}
+	if below == 0 || above == 0 || float64(below)/float64(above) > 2 || float64(above)/float64(below) > 2 {
+		t.Errorf("%d pixels in the first half of the palette and %d in the second one", below, above)
+	}
+}
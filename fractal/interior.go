@@ -0,0 +1,51 @@
+package fractal
+
+// PeriodicityTolerance is the distance under which an orbit is considered to
+// have come back to a previous value. The orbits of the points of the set
+// fall into cycles, detecting them stops their iteration early.
+const PeriodicityTolerance = 1e-15
+
+// InsideMainBulbs reports whether the point (x, y) belongs to the main
+// cardioid or the period-2 bulb of the Mandelbrot set, the biggest areas of
+// the set, which are detected without iterating.
+func InsideMainBulbs(x float64, y float64) bool {
+	// Period-2 bulb: the circle of radius 1/4 centered at -1
+	if (x+1)*(x+1)+y*y <= 0.0625 {
+		return true
+	}
+
+	// Main cardioid: q·(q + x - 1/4) <= y²/4 with q = (x - 1/4)² + y²
+	q := (x-0.25)*(x-0.25) + y*y
+	return q*(q+x-0.25) <= 0.25*y*y
+}
+
+// periodicityCheck detects the cycles of an orbit with Brent's algorithm: the
+// orbit is compared with a saved value that is replaced at power of two
+// intervals, so cycles of any length are found in a few times their period.
+type periodicityCheck struct {
+	savedReal      float64
+	savedImaginary float64
+	steps          int
+	interval       int
+}
+
+func newPeriodicityCheck(zr float64, zi float64) periodicityCheck {
+	return periodicityCheck{savedReal: zr, savedImaginary: zi, interval: 1}
+}
+
+// cycled reports whether the orbit came back to the saved value at z, which
+// is the next value of the orbit.
+func (p *periodicityCheck) cycled(zr float64, zi float64) bool {
+	if zr-p.savedReal < PeriodicityTolerance && p.savedReal-zr < PeriodicityTolerance &&
+		zi-p.savedImaginary < PeriodicityTolerance && p.savedImaginary-zi < PeriodicityTolerance {
+		return true
+	}
+
+	p.steps++
+	if p.steps == p.interval {
+		p.steps = 0
+		p.interval *= 2
+		p.savedReal, p.savedImaginary = zr, zi
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package fractal
+
+import "testing"
+
+func TestInsideMainBulbs(t *testing.T) {
+	tests := []struct {
+		x, y   float64
+		inside bool
+	}{
+		{0, 0, true},
+		{-0.7, 0, true},
+		{0.24, 0, true},
+		{-1, 0, true},
+		{-1.2, 0.05, true},
+		{0.26, 0, false},
+		{-1.3, 0, false},   // period-4 bulb
+		{-0.1, 0.8, false}, // period-3 bulb
+		{1, 1, false},
+	}
+
+	for _, test := range tests {
+		if inside := InsideMainBulbs(test.x, test.y); inside != test.inside {
+			t.Errorf("InsideMainBulbs(%v, %v) = %v, want %v", test.x, test.y, inside, test.inside)
+		}
+	}
+}
+
+// iterateNaive is Iterate without interior detection.
+func iterateNaive(x float64, y float64, maxIterations float64) float64 {
+	realComponent, imaginaryComponent := x, y
+	for i := float64(0); i < maxIterations; i++ {
+		realComponent, imaginaryComponent = realComponent*realComponent-imaginaryComponent*imaginaryComponent+x, 2*realComponent*imaginaryComponent+y
+		if realComponent*realComponent+imaginaryComponent*imaginaryComponent > 4 {
+			return i
+		}
+	}
+	return maxIterations
+}
+
+func TestInteriorDetectionMatchesIteration(t *testing.T) {
+	for x := -2.0; x <= 0.5; x += 0.0125 {
+		for y := -1.2; y <= 1.2; y += 0.0125 {
+			want := iterateNaive(x, y, 2000)
+			if got, _ := Iterate(x, y, 2000, DefaultBailoutRadius); got != want {
+				t.Errorf("Iterate(%v, %v) = %v, want %v", x, y, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package fractal
+
+import "image"
+
+// IterationBuffer keeps the iteration count, the final |z| and the estimated
+// distance to the set of every sample of the pixels of a region of a frame,
+// so the region can be recolored (other palette, max iterations or coloring
+// mode) without iterating again.
+type IterationBuffer struct {
+	Region            Region    // Pixels of the frame stored
+	Samples           int32     // Samples per pixel, see Viewport.Samples
+	MaxIterations     float64   // Max iterations of the frame, the count of the points of the set
+	EstimatedDistance bool      // The distance to the set was estimated
+	Iterations        []float32 // Row-major iteration at which the orbit of every sample escaped, the samples of a pixel are consecutive
+	Modulus           []float32 // Row-major |z| of every sample at the iteration it escaped
+	Distance          []float32 // Row-major distance of every sample to the set in pixels, 0 when it wasn't estimated
+}
+
+func NewIterationBuffer(region Region, samples int32) *IterationBuffer {
+	return &IterationBuffer{
+		Region:     region,
+		Samples:    samples,
+		Iterations: make([]float32, region.Width()*region.Height()*samples),
+		Modulus:    make([]float32, region.Width()*region.Height()*samples),
+		Distance:   make([]float32, region.Width()*region.Height()*samples),
+	}
+}
+
+func (b *IterationBuffer) index(x int32, y int32, sample int32) int32 {
+	return ((y-b.Region.YStart)*b.Region.Width()+x-b.Region.XStart)*b.Samples + sample
+}
+
+// Set stores the iteration count, the |z| and the distance to the set of a
+// sample of the pixel at position (x, y).
+func (b *IterationBuffer) Set(x int32, y int32, sample int32, i float64, modulus float64, distance float64) {
+	index := b.index(x, y, sample)
+	b.Iterations[index] = float32(i)
+	b.Modulus[index] = float32(modulus)
+	b.Distance[index] = float32(distance)
+}
+
+// At returns the iteration count, the |z| and the distance to the set of a
+// sample of the pixel at position (x, y).
+func (b *IterationBuffer) At(x int32, y int32, sample int32) (float64, float64, float64) {
+	index := b.index(x, y, sample)
+	return float64(b.Iterations[index]), float64(b.Modulus[index]), float64(b.Distance[index])
+}
+
+// CanRecolor reports whether the buffer holds the data needed to color the
+// viewport: the counts are only valid for equal or lower max iterations and
+// the same samples, and the distance coloring modes need the distance
+// estimation.
+func (b *IterationBuffer) CanRecolor(viewport Viewport) bool {
+	return viewport.MaxIterations <= b.MaxIterations && viewport.Samples() == b.Samples &&
+		(b.EstimatedDistance || !viewport.Coloring.UsesDistance())
+}
+
+// Recolor paints the region of img with the colors of viewport for the
+// iteration counts stored in the buffer. The histogram is the distribution of
+// the frame used by the histogram coloring, ignored by the rest of modes.
+func (b *IterationBuffer) Recolor(viewport Viewport, histogram *Histogram, img *image.RGBA, region Region) {
+	degree := viewport.IteratedFormula().Degree()
+	for y := region.YStart; y <= region.YEnd; y++ {
+		for x := region.XStart; x <= region.XEnd; x++ {
+			var average colorAverage
+			for sample := int32(0); sample < b.Samples; sample++ {
+				i, modulus, distance := b.At(x, y, sample)
+				if viewport.Coloring == HistogramColoring && histogram != nil {
+					average.add(histogram.PixelColor(viewport, i, modulus, degree))
+				} else {
+					average.add(viewport.PixelColor(i, modulus, distance, degree))
+				}
+			}
+			img.SetRGBA(int(x), int(y), average.color())
+		}
+	}
+}
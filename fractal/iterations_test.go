@@ -0,0 +1,36 @@
+package fractal
+
+import (
+	"bytes"
+	"image"
+	"testing"
+)
+
+func TestIterationBufferRecolor(t *testing.T) {
+	viewport := Viewport{Width: 160, Height: 90, MagnificationFactor: NewFloat(50), MaxIterations: 100, PanX: NewFloat(2), PanY: NewFloat(0.9)}
+	region := Region{XStart: 40, YStart: 10, XEnd: 119, YEnd: 79}
+	iterations := NewIterationBuffer(region, 1)
+	img := image.NewRGBA(region.Rect())
+	NewRenderer(4).CalculateRegionLocally(viewport, img, iterations, region)
+
+	// Other coloring settings and fewer iterations match a new render
+	recolored := viewport
+	recolored.Coloring = SmoothColoring
+	recolored.MaxIterations = 40
+	recolored.Palette = Palettes[1].WithOffset(0.3)
+	if !iterations.CanRecolor(recolored) {
+		t.Fatalf("CanRecolor(%v iterations) = false for a buffer of %v iterations", recolored.MaxIterations, iterations.MaxIterations)
+	}
+	iterations.Recolor(recolored, nil, img, region)
+
+	want := image.NewRGBA(region.Rect())
+	NewRenderer(4).CalculateRegionLocally(recolored, want, nil, region)
+	if !bytes.Equal(img.Pix, want.Pix) {
+		t.Errorf("recolored region differs from the rendered one")
+	}
+
+	recolored.MaxIterations = 200
+	if iterations.CanRecolor(recolored) {
+		t.Errorf("CanRecolor(%v iterations) = true for a buffer of %v iterations", recolored.MaxIterations, iterations.MaxIterations)
+	}
+}
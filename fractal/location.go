@@ -0,0 +1,156 @@
+package fractal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+)
+
+// Location is a named view of a fractal, saved into location files to get
+// back to it or to share it.
+type Location struct {
+	Name          string
+	CenterX       *big.Float // Point of the complex plane at the center of the frame
+	CenterY       *big.Float
+	Magnification *big.Float // Pixels per unit of the complex plane
+	MaxIterations float64
+	Formula       Formula
+	Palette       *Palette // Colors of the view, the viewport keeps its palette when nil
+}
+
+// locationJSON is the format of the locations in location files, the numbers
+// of the center and the magnification keep all their digits as strings:
+//
+// {"name": "seahorse", "center": ["-0.7436", "0.1318"], "magnification": "1e5", "iterations": 1000, "formula": "mandelbrot"}
+type locationJSON struct {
+	Name          string          `json:"name"`
+	Center        [2]string       `json:"center"`
+	Magnification string          `json:"magnification"`
+	Iterations    float64         `json:"iterations"`
+	Formula       string          `json:"formula,omitempty"`
+	Palette       json.RawMessage `json:"palette,omitempty"`
+}
+
+// Location returns the current view of the viewport as a location.
+func (v Viewport) Location(name string) Location {
+	x, y := v.Center()
+	return Location{
+		Name:          name,
+		CenterX:       x,
+		CenterY:       y,
+		Magnification: v.MagnificationFactor,
+		MaxIterations: v.MaxIterations,
+		Formula:       v.IteratedFormula(),
+		Palette:       v.Palette,
+	}
+}
+
+// GoTo moves the viewport to the location, keeping its size and coloring
+// mode.
+func (v *Viewport) GoTo(l Location) {
+	v.MagnificationFactor = l.Magnification
+	v.MaxIterations = l.MaxIterations
+	v.Formula = l.Formula
+	if l.Palette != nil {
+		v.Palette = l.Palette
+	}
+	v.ReferenceOrbit = nil
+	v.SetCenter(l.CenterX, l.CenterY)
+}
+
+// MarshalJSON encodes the location in the format read by ParseLocations.
+func (l Location) MarshalJSON() ([]byte, error) {
+	definition := locationJSON{
+		Name:          l.Name,
+		Center:        [2]string{l.CenterX.Text('g', -1), l.CenterY.Text('g', -1)},
+		Magnification: l.Magnification.Text('g', -1),
+		Iterations:    l.MaxIterations,
+	}
+	if l.Formula != nil {
+		definition.Formula = l.Formula.String()
+	}
+	if l.Palette != nil {
+		palette, err := l.Palette.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		definition.Palette = palette
+	}
+	return json.Marshal(definition)
+}
+
+// UnmarshalJSON decodes a location in the format written by MarshalJSON.
+func (l *Location) UnmarshalJSON(data []byte) error {
+	var definition locationJSON
+	if err := json.Unmarshal(data, &definition); err != nil {
+		return err
+	}
+
+	location := Location{Name: definition.Name, MaxIterations: definition.Iterations, Formula: Mandelbrot{}}
+	var err error
+	if location.CenterX, err = ParseFloat(definition.Center[0]); err != nil {
+		return fmt.Errorf("invalid location %q: center %v", definition.Name, err)
+	}
+	if location.CenterY, err = ParseFloat(definition.Center[1]); err != nil {
+		return fmt.Errorf("invalid location %q: center %v", definition.Name, err)
+	}
+	if location.Magnification, err = ParseFloat(definition.Magnification); err != nil {
+		return fmt.Errorf("invalid location %q: magnification %v", definition.Name, err)
+	}
+	if location.Magnification.Sign() <= 0 {
+		return fmt.Errorf("invalid location %q: magnification %v", definition.Name, definition.Magnification)
+	}
+	if location.MaxIterations <= 0 {
+		return fmt.Errorf("invalid location %q: iterations %v", definition.Name, definition.Iterations)
+	}
+	if definition.Formula != "" {
+		if location.Formula, err = ParseFormula(definition.Formula); err != nil {
+			return fmt.Errorf("invalid location %q: %v", definition.Name, err)
+		}
+	}
+	if len(definition.Palette) > 0 {
+		if location.Palette, err = ParsePalette(definition.Palette); err != nil {
+			return fmt.Errorf("invalid location %q: %v", definition.Name, err)
+		}
+	}
+	*l = location
+	return nil
+}
+
+// ParseLocations parses a location file: a JSON list of locations or a
+// single location.
+func ParseLocations(data []byte) ([]Location, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var locations []Location
+		if err := json.Unmarshal(trimmed, &locations); err != nil {
+			return nil, fmt.Errorf("invalid locations: %v", err)
+		}
+		return locations, nil
+	}
+
+	var location Location
+	if err := json.Unmarshal(data, &location); err != nil {
+		return nil, fmt.Errorf("invalid locations: %v", err)
+	}
+	return []Location{location}, nil
+}
+
+// LoadLocations reads the locations of a location file.
+func LoadLocations(path string) ([]Location, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseLocations(data)
+}
+
+// SaveLocations writes the locations into a location file.
+func SaveLocations(path string, locations []Location) error {
+	data, err := json.MarshalIndent(locations, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0644)
+}
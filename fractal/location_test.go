@@ -0,0 +1,69 @@
+package fractal
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestLocationRoundTrip(t *testing.T) {
+	x, _ := ParseFloat("-0.743643887037158704752191506114774")
+	y, _ := ParseFloat("0.131825904205311970493132056385139")
+	viewport := Viewport{Width: 1280, Height: 720, MagnificationFactor: NewFloat(1e14), MaxIterations: 3000, Formula: Multibrot{Power: 3}, Palette: Palettes[1].WithOffset(0.25)}
+	viewport.SetCenter(x, y)
+
+	data, err := json.Marshal([]Location{viewport.Location("deep")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	locations, err := ParseLocations(data)
+	if err != nil {
+		t.Fatalf("ParseLocations(%s) error %v", data, err)
+	}
+	if len(locations) != 1 || locations[0].Name != "deep" {
+		t.Fatalf("ParseLocations(%s) = %v, want the deep location", data, locations)
+	}
+
+	// The center keeps more digits than the pixels of the frame need
+	moved := Viewport{Width: 1280, Height: 720}
+	moved.GoTo(locations[0])
+	cx, cy := moved.Center()
+	dx, dy := new(big.Float).Sub(cx, x), new(big.Float).Sub(cy, y)
+	if d, _ := dx.Float64(); math.Abs(d) > 1e-20 {
+		t.Errorf("center x %v, want %v", cx.Text('g', 40), x.Text('g', 40))
+	}
+	if d, _ := dy.Float64(); math.Abs(d) > 1e-20 {
+		t.Errorf("center y %v, want %v", cy.Text('g', 40), y.Text('g', 40))
+	}
+	if moved.MagnificationFactor.Cmp(viewport.MagnificationFactor) != 0 || moved.MaxIterations != 3000 {
+		t.Errorf("magnification %v and %v iterations, want %v and 3000", moved.MagnificationFactor, moved.MaxIterations, viewport.MagnificationFactor)
+	}
+	if moved.IteratedFormula() != viewport.Formula {
+		t.Errorf("formula %v, want %v", moved.IteratedFormula(), viewport.Formula)
+	}
+	if moved.Palette.Name != "ultrafractal" || moved.Palette.Offset != 0.25 || len(moved.Palette.Stops) != len(Palettes[1].Stops) {
+		t.Errorf("palette %+v, want ultrafractal with offset 0.25", moved.Palette)
+	}
+}
+
+func TestParseLocations(t *testing.T) {
+	single := `{"name": "seahorse", "center": ["-0.7436", "0.1318"], "magnification": "1e5", "iterations": 1000}`
+	locations, err := ParseLocations([]byte(single))
+	if err != nil || len(locations) != 1 || locations[0].Formula != (Mandelbrot{}) || locations[0].Palette != nil {
+		t.Errorf("ParseLocations(%s) = %v, %v, want a Mandelbrot location without palette", single, locations, err)
+	}
+
+	invalid := []string{
+		`[{"name": "a", "center": ["x", "0"], "magnification": "1", "iterations": 10}]`,
+		`{"name": "a", "center": ["0", "0"], "magnification": "-1", "iterations": 10}`,
+		`{"name": "a", "center": ["0", "0"], "magnification": "1", "iterations": 0}`,
+		`{"name": "a", "center": ["0", "0"], "magnification": "1", "iterations": 10, "formula": "spiral"}`,
+		`[1, 2]`,
+	}
+	for _, data := range invalid {
+		if _, err := ParseLocations([]byte(data)); err == nil {
+			t.Errorf("ParseLocations(%s) didn't fail", data)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package fractal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ColorStop is a color of a gradient at a position within [0, 1].
+type ColorStop struct {
+	Position float64
+	Color    color.RGBA
+}
+
+// Palette is a cyclic gradient of colors used to paint the iteration counts.
+// Palettes are never modified in place, the With methods return copies.
+type Palette struct {
+	Name   string
+	Stops  []ColorStop // Sorted by position, the last stop blends into the first one
+	Offset float64     // Shift of the gradient, within [0, 1)
+	Scale  float64     // Times the gradient repeats from 0 to MaxIterations, 1 when 0
+}
+
+// Built-in palettes, the first one is the default.
+var Palettes = []*Palette{
+	huePalette(),
+	{Name: "ultrafractal", Stops: []ColorStop{
+		{0, color.RGBA{0, 7, 100, 255}},
+		{0.16, color.RGBA{32, 107, 203, 255}},
+		{0.42, color.RGBA{237, 255, 255, 255}},
+		{0.6425, color.RGBA{255, 170, 0, 255}},
+		{0.8575, color.RGBA{0, 2, 0, 255}},
+	}},
+	{Name: "fire", Stops: []ColorStop{
+		{0, color.RGBA{0, 0, 0, 255}},
+		{0.25, color.RGBA{128, 0, 0, 255}},
+		{0.5, color.RGBA{255, 96, 0, 255}},
+		{0.75, color.RGBA{255, 230, 80, 255}},
+		{0.9, color.RGBA{255, 255, 255, 255}},
+	}},
+	{Name: "ocean", Stops: []ColorStop{
+		{0, color.RGBA{0, 12, 40, 255}},
+		{0.3, color.RGBA{0, 90, 160, 255}},
+		{0.6, color.RGBA{80, 200, 220, 255}},
+		{0.8, color.RGBA{230, 250, 255, 255}},
+	}},
+	{Name: "grayscale", Stops: []ColorStop{
+		{0, color.RGBA{0, 0, 0, 255}},
+		{0.5, color.RGBA{255, 255, 255, 255}},
+	}},
+}
+
+// huePalette is the hue bar of the HSV color space. HSV colors are linear in
+// RGB between multiples of 60º so 7 stops reproduce it exactly.
+func huePalette() *Palette {
+	saturation, value := 0.98, 0.922
+	high := uint8(value * 255)
+	low := uint8(value * (1 - saturation) * 255)
+	return &Palette{Name: "hue", Stops: []ColorStop{
+		{0, color.RGBA{high, low, low, 255}},
+		{1.0 / 6, color.RGBA{high, high, low, 255}},
+		{2.0 / 6, color.RGBA{low, high, low, 255}},
+		{3.0 / 6, color.RGBA{low, high, high, 255}},
+		{4.0 / 6, color.RGBA{low, low, high, 255}},
+		{5.0 / 6, color.RGBA{high, low, high, 255}},
+	}}
+}
+
+// LookupPalette returns the built-in palette with the given name.
+func LookupPalette(name string) (*Palette, error) {
+	for _, palette := range Palettes {
+		if palette.Name == name {
+			return palette, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown palette %q", name)
+}
+
+// LoadPalette returns the built-in palette with the given name, or loads it
+// from a file when name is a path to a JSON gradient (see ParsePalette) or a
+// Fractint .map file.
+func LoadPalette(name string) (*Palette, error) {
+	if palette, err := LookupPalette(name); err == nil {
+		return palette, nil
+	}
+
+	data, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("unknown palette %q: %v", name, err)
+	}
+
+	if strings.EqualFold(filepath.Ext(name), ".map") {
+		return ParseMapPalette(strings.TrimSuffix(filepath.Base(name), filepath.Ext(name)), string(data))
+	}
+	return ParsePalette(data)
+}
+
+// paletteJSON is the format of the gradient files:
+//
+// {"name": "sunset", "stops": [{"position": 0, "color": "#1a0533"}, {"position": 0.5, "color": "#ff7b00"}]}
+type paletteJSON struct {
+	Name   string          `json:"name"`
+	Stops  []colorStopJSON `json:"stops"`
+	Offset float64         `json:"offset,omitempty"`
+	Scale  float64         `json:"scale,omitempty"`
+}
+
+type colorStopJSON struct {
+	Position float64 `json:"position"`
+	Color    string  `json:"color"`
+}
+
+// ParsePalette parses a JSON gradient definition.
+func ParsePalette(data []byte) (*Palette, error) {
+	var definition paletteJSON
+	if err := json.Unmarshal(data, &definition); err != nil {
+		return nil, fmt.Errorf("invalid palette: %v", err)
+	}
+
+	palette := &Palette{Name: definition.Name, Offset: definition.Offset, Scale: definition.Scale}
+	for _, stop := range definition.Stops {
+		c, err := parseHexColor(stop.Color)
+		if err != nil {
+			return nil, fmt.Errorf("invalid palette %q: %v", definition.Name, err)
+		}
+		if stop.Position < 0 || stop.Position > 1 {
+			return nil, fmt.Errorf("invalid palette %q: stop position %v out of [0, 1]", definition.Name, stop.Position)
+		}
+		palette.Stops = append(palette.Stops, ColorStop{Position: stop.Position, Color: c})
+	}
+
+	if len(palette.Stops) == 0 {
+		return nil, fmt.Errorf("invalid palette %q: no color stops", definition.Name)
+	}
+	sort.SliceStable(palette.Stops, func(i, j int) bool { return palette.Stops[i].Position < palette.Stops[j].Position })
+	return palette, nil
+}
+
+// MarshalJSON encodes the palette in the format read by ParsePalette.
+func (p *Palette) MarshalJSON() ([]byte, error) {
+	definition := paletteJSON{Name: p.Name, Offset: p.Offset, Scale: p.Scale}
+	for _, stop := range p.Stops {
+		definition.Stops = append(definition.Stops, colorStopJSON{Position: stop.Position, Color: fmt.Sprintf("#%02x%02x%02x", stop.Color.R, stop.Color.G, stop.Color.B)})
+	}
+	return json.Marshal(definition)
+}
+
+// ParseMapPalette parses a Fractint .map palette: one "red green blue" line
+// per color, spread evenly along the gradient. Text after the third number of
+// a line is a comment.
+func ParseMapPalette(name string, data string) (*Palette, error) {
+	var colors []color.RGBA
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("invalid palette %q: line %q", name, scanner.Text())
+		}
+
+		var rgb [3]uint8
+		for i := range rgb {
+			component, err := strconv.ParseUint(fields[i], 10, 8)
+			if err != nil {
+				return nil, fmt.Errorf("invalid palette %q: %v", name, err)
+			}
+			rgb[i] = uint8(component)
+		}
+		colors = append(colors, color.RGBA{rgb[0], rgb[1], rgb[2], 255})
+	}
+
+	if len(colors) == 0 {
+		return nil, fmt.Errorf("invalid palette %q: no colors", name)
+	}
+
+	palette := &Palette{Name: name}
+	for i, c := range colors {
+		palette.Stops = append(palette.Stops, ColorStop{Position: float64(i) / float64(len(colors)), Color: c})
+	}
+	return palette, nil
+}
+
+func parseHexColor(s string) (color.RGBA, error) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) != 6 {
+		return color.RGBA{}, fmt.Errorf("invalid color %q, expected #rrggbb", s)
+	}
+	rgb, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("invalid color %q, expected #rrggbb", s)
+	}
+	return color.RGBA{uint8(rgb >> 16), uint8(rgb >> 8), uint8(rgb), 255}, nil
+}
+
+// WithOffset returns a copy of the palette shifted to offset, wrapped to [0, 1).
+func (p *Palette) WithOffset(offset float64) *Palette {
+	palette := *p
+	palette.Offset = offset - math.Floor(offset)
+	return &palette
+}
+
+// WithScale returns a copy of the palette repeated scale times.
+func (p *Palette) WithScale(scale float64) *Palette {
+	palette := *p
+	palette.Scale = scale
+	return &palette
+}
+
+// Color returns the color of the gradient at position t, the gradient repeats
+// every unit.
+func (p *Palette) Color(t float64) color.RGBA {
+	t -= math.Floor(t)
+	stops := p.Stops
+
+	// Find the stops around t, wrapping from the last stop to the first one
+	next := sort.Search(len(stops), func(i int) bool { return stops[i].Position > t })
+	previous := next - 1
+	var from, to ColorStop
+	if previous < 0 {
+		from, to = stops[len(stops)-1], stops[0]
+		from.Position--
+	} else if next == len(stops) {
+		from, to = stops[previous], stops[0]
+		to.Position++
+	} else {
+		from, to = stops[previous], stops[next]
+	}
+
+	f := 0.0
+	if to.Position > from.Position {
+		f = (t - from.Position) / (to.Position - from.Position)
+	}
+	return color.RGBA{
+		uint8(float64(from.Color.R) + f*(float64(to.Color.R)-float64(from.Color.R))),
+		uint8(float64(from.Color.G) + f*(float64(to.Color.G)-float64(from.Color.G))),
+		uint8(float64(from.Color.B) + f*(float64(to.Color.B)-float64(from.Color.B))),
+		255,
+	}
+}
+
+// IterationColor maps the iteration at which a point escaped to a color of
+// the palette.
+func (p *Palette) IterationColor(i float64, maxIterations float64) color.RGBA {
+	scale := p.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	return p.Color(i*scale/maxIterations + p.Offset)
+}
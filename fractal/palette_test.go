@@ -0,0 +1,99 @@
+package fractal
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestHuePaletteMatchesIterationColor(t *testing.T) {
+	palette, err := LookupPalette("hue")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := float64(0); i < 80; i += 0.25 {
+		got, want := palette.IterationColor(i, 80), IterationColor(i, 80)
+		if diff(got.R, want.R) > 1 || diff(got.G, want.G) > 1 || diff(got.B, want.B) > 1 {
+			t.Errorf("IterationColor(%v) = %v, want %v", i, got, want)
+		}
+	}
+}
+
+func diff(a uint8, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func TestPaletteColor(t *testing.T) {
+	black, white := color.RGBA{0, 0, 0, 255}, color.RGBA{255, 255, 255, 255}
+	palette := &Palette{Name: "test", Stops: []ColorStop{{0.25, black}, {0.75, white}}}
+
+	tests := []struct {
+		t    float64
+		want color.RGBA
+	}{
+		{0.25, black},
+		{0.5, color.RGBA{127, 127, 127, 255}},
+		{0.75, white},
+		{1, color.RGBA{127, 127, 127, 255}}, // wraps from the last stop to the first one
+		{1.25, black},
+		{-0.25, white},
+	}
+	for _, test := range tests {
+		if got := palette.Color(test.t); got != test.want {
+			t.Errorf("Color(%v) = %v, want %v", test.t, got, test.want)
+		}
+	}
+
+	shifted := palette.WithOffset(1.5).WithScale(2)
+	if shifted.Offset != 0.5 || shifted.Scale != 2 || palette.Offset != 0 || palette.Scale != 0 {
+		t.Errorf("WithOffset/WithScale = %+v from %+v", shifted, palette)
+	}
+	if got := shifted.IterationColor(30, 80); got != black { // 30 * 2 / 80 + 0.5 = 1.25
+		t.Errorf("IterationColor(30, 80) = %v, want %v", got, black)
+	}
+}
+
+func TestParsePalette(t *testing.T) {
+	palette, err := ParsePalette([]byte(`{"name": "sunset", "stops": [{"position": 0.5, "color": "#ff7b00"}, {"position": 0, "color": "#1A0533"}], "scale": 3}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ColorStop{{0, color.RGBA{0x1a, 0x05, 0x33, 255}}, {0.5, color.RGBA{0xff, 0x7b, 0x00, 255}}}
+	if palette.Name != "sunset" || palette.Scale != 3 || len(palette.Stops) != 2 || palette.Stops[0] != want[0] || palette.Stops[1] != want[1] {
+		t.Errorf("ParsePalette = %+v, want stops %v", palette, want)
+	}
+
+	encoded, err := palette.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ParsePalette(encoded)
+	if err != nil || decoded.Name != palette.Name || decoded.Scale != palette.Scale || decoded.Stops[1] != palette.Stops[1] {
+		t.Errorf("ParsePalette(%s) = %+v, %v", encoded, decoded, err)
+	}
+
+	for _, definition := range []string{`{}`, `{"stops": [{"position": 0, "color": "red"}]}`, `{"stops": [{"position": 2, "color": "#000000"}]}`, `[`} {
+		if _, err := ParsePalette([]byte(definition)); err == nil {
+			t.Errorf("ParsePalette(%s) didn't fail", definition)
+		}
+	}
+}
+
+func TestParseMapPalette(t *testing.T) {
+	palette, err := ParseMapPalette("test", "0 0 0 black\n\n255 128 0\n0 0 255 blue\n255 255 255\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(palette.Stops) != 4 || palette.Stops[1] != (ColorStop{0.25, color.RGBA{255, 128, 0, 255}}) || palette.Stops[3].Position != 0.75 {
+		t.Errorf("ParseMapPalette = %+v", palette)
+	}
+
+	for _, data := range []string{"", "0 0\n", "0 0 256\n"} {
+		if _, err := ParseMapPalette("test", data); err == nil {
+			t.Errorf("ParseMapPalette(%q) didn't fail", data)
+		}
+	}
+}
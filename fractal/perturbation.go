@@ -0,0 +1,206 @@
+package fractal
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/cmplx"
+)
+
+// DeepZoomMode selects how viewports zoomed beyond MaxFloat64Magnification
+// are calculated.
+type DeepZoomMode int32
+
+const (
+	// PerturbationDeepZoom iterates float64 deltas against a single arbitrary
+	// precision reference orbit, up to MaxPerturbationMagnification.
+	PerturbationDeepZoom DeepZoomMode = iota
+	// ArbitraryPrecisionDeepZoom iterates every pixel with math/big, which is
+	// exact but very slow.
+	ArbitraryPrecisionDeepZoom
+)
+
+var deepZoomModeNames = map[DeepZoomMode]string{
+	PerturbationDeepZoom:       "perturbation",
+	ArbitraryPrecisionDeepZoom: "arbitrary",
+}
+
+func (d DeepZoomMode) String() string {
+	if name, ok := deepZoomModeNames[d]; ok {
+		return name
+	}
+	return fmt.Sprintf("DeepZoomMode(%d)", int32(d))
+}
+
+// ParseDeepZoomMode returns the deep zoom mode with the given name.
+func ParseDeepZoomMode(name string) (DeepZoomMode, error) {
+	for mode, modeName := range deepZoomModeNames {
+		if modeName == name {
+			return mode, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown deep zoom mode %q", name)
+}
+
+// SeriesApproximationTolerance is the max size of the truncated cubic term of
+// the series approximation relative to its linear term.
+const SeriesApproximationTolerance = 1e-6
+
+// ReferenceOrbit is the orbit Z(n+1) = Z(n)² + C of the center C of a
+// viewport, calculated with arbitrary precision and rounded to float64. The
+// orbits of the rest of the pixels are calculated as float64 deltas from it.
+//
+// The first iterations of the deltas are shared by all the pixels of the
+// frame and are skipped with a series approximation: after SkippedIterations
+// the delta of a pixel at distance dc from C is SeriesA·dc + SeriesB·dc² +
+// SeriesC·dc³.
+type ReferenceOrbit struct {
+	Real              []float64
+	Imaginary         []float64
+	SkippedIterations int
+	SeriesA           complex128
+	SeriesB           complex128
+	SeriesC           complex128
+}
+
+// NewReferenceOrbit calculates the reference orbit of the center of the
+// viewport, starting at Z(0) = 0, until it escapes or reaches the max
+// iterations of the viewport.
+func NewReferenceOrbit(viewport Viewport) *ReferenceOrbit {
+	precision := viewport.Precision()
+	x, y := viewport.Center()
+	realComponent := new(big.Float).SetPrec(precision)
+	imaginaryComponent := new(big.Float).SetPrec(precision)
+	realSquared := new(big.Float).SetPrec(precision)
+	imaginarySquared := new(big.Float).SetPrec(precision)
+	bailout := viewport.Bailout()
+
+	orbit := &ReferenceOrbit{Real: []float64{0}, Imaginary: []float64{0}}
+	for i := float64(0); i <= viewport.MaxIterations; i++ {
+		realSquared.Mul(realComponent, realComponent)
+		imaginarySquared.Mul(imaginaryComponent, imaginaryComponent)
+
+		imaginaryComponent.Mul(imaginaryComponent, realComponent)
+		imaginaryComponent.Add(imaginaryComponent, imaginaryComponent).Add(imaginaryComponent, y)
+		realComponent.Sub(realSquared, imaginarySquared).Add(realComponent, x)
+
+		realFloat, _ := realComponent.Float64()
+		imaginaryFloat, _ := imaginaryComponent.Float64()
+		orbit.Real = append(orbit.Real, realFloat)
+		orbit.Imaginary = append(orbit.Imaginary, imaginaryFloat)
+		if realFloat*realFloat+imaginaryFloat*imaginaryFloat > bailout*bailout {
+			break
+		}
+	}
+
+	// The series must be accurate for the farthest pixel, at the corners
+	magnificationFactor, _ := viewport.MagnificationFactor.Float64()
+	orbit.approximateSeries(math.Hypot(float64(viewport.Width), float64(viewport.Height)) / (2 * magnificationFactor))
+	return orbit
+}
+
+// approximateSeries calculates the coefficients of the series approximation
+// of the deltas dz(n) = A(n)·dc + B(n)·dc² + C(n)·dc³ for the pixels at a
+// distance up to radius of the reference point, skipping iterations while the
+// truncated terms stay negligible:
+//
+// A(n+1) = 2·Z(n)·A(n) + 1, B(n+1) = 2·Z(n)·B(n) + A(n)², C(n+1) = 2·Z(n)·C(n) + 2·A(n)·B(n)
+func (o *ReferenceOrbit) approximateSeries(radius float64) {
+	// dz(1) = dc
+	a, b, c := complex(1, 0), complex(0, 0), complex(0, 0)
+	n := 1
+
+	for ; n+1 < len(o.Real)-1; n++ {
+		z := complex(o.Real[n], o.Imaginary[n])
+		nextA := 2*z*a + 1
+		nextB := 2*z*b + a*a
+		nextC := 2*z*c + 2*a*b
+
+		linear := cmplx.Abs(nextA) * radius
+		cubic := cmplx.Abs(nextC) * radius * radius * radius
+		if math.IsInf(cubic, 0) || math.IsNaN(cubic) || cubic > SeriesApproximationTolerance*linear {
+			break
+		}
+		a, b, c = nextA, nextB, nextC
+	}
+
+	o.SkippedIterations = n - 1
+	o.SeriesA, o.SeriesB, o.SeriesC = a, b, c
+}
+
+// IteratePerturbation is the perturbation version of Iterate for the point
+// at distance (dx, dy) from the reference point of the orbit.
+//
+// The delta of the pixel orbit z(n) = Z(n) + dz(n) is iterated in float64 as
+// dz(n+1) = 2·Z(n)·dz(n) + dz(n)² + dc. When |z(n)| gets smaller than |dz(n)|
+// the delta loses its precision (a glitch), and when the reference orbit
+// escapes or ends it can't be followed anymore. In both cases the pixel is
+// rebased: dz takes the value of z and the reference restarts at Z(0) = 0.
+func IteratePerturbation(orbit *ReferenceOrbit, dx float64, dy float64, maxIterations float64, bailoutRadius float64) (float64, float64) {
+	i, modulusSquared, _ := iteratePerturbation(orbit, dx, dy, maxIterations, bailoutRadius, false)
+	return i, modulusSquared
+}
+
+// IteratePerturbationDistance is the version of IteratePerturbation that also
+// returns the modulus of the derivative dz/dc of the orbit at the iteration it
+// escaped, 0 when the point belongs to the set.
+func IteratePerturbationDistance(orbit *ReferenceOrbit, dx float64, dy float64, maxIterations float64, bailoutRadius float64) (float64, float64, float64) {
+	return iteratePerturbation(orbit, dx, dy, maxIterations, bailoutRadius, true)
+}
+
+func iteratePerturbation(orbit *ReferenceOrbit, dx float64, dy float64, maxIterations float64, bailoutRadius float64, trackDerivative bool) (float64, float64, float64) {
+	bailoutSquared := bailoutRadius * bailoutRadius
+	last := len(orbit.Real) - 1
+
+	// Iterate starts at z(1) = c, so does the delta: Z(1) = C and dz(1) = dc.
+	// The series approximation moves the start forward.
+	m := 1 + orbit.SkippedIterations
+	dc := complex(dx, dy)
+	delta := orbit.SeriesA*dc + orbit.SeriesB*dc*dc + orbit.SeriesC*dc*dc*dc
+	deltaReal, deltaImaginary := real(delta), imag(delta)
+	if m == last {
+		// The reference escaped at once, start rebased
+		m, deltaReal, deltaImaginary = 0, orbit.Real[last]+deltaReal, orbit.Imaginary[last]+deltaImaginary
+	}
+	var referenceReal, referenceImaginary, realComponent, imaginaryComponent, modulusSquared float64
+
+	// The derivative of the series is A + 2·B·dc + 3·C·dc²
+	derivative := orbit.SeriesA + 2*orbit.SeriesB*dc + 3*orbit.SeriesC*dc*dc
+	derivativeReal, derivativeImaginary := real(derivative), imag(derivative)
+
+	for i := float64(orbit.SkippedIterations); i < maxIterations; i++ {
+		referenceReal, referenceImaginary = orbit.Real[m], orbit.Imaginary[m]
+		if trackDerivative {
+			// dz/dc(n+1) = 2·z(n)·dz/dc(n) + 1 with the full value of z(n)
+			realComponent, imaginaryComponent = referenceReal+deltaReal, referenceImaginary+deltaImaginary
+			derivativeReal, derivativeImaginary =
+				2*(realComponent*derivativeReal-imaginaryComponent*derivativeImaginary)+1,
+				2*(realComponent*derivativeImaginary+imaginaryComponent*derivativeReal)
+		}
+		deltaReal, deltaImaginary =
+			2*(referenceReal*deltaReal-referenceImaginary*deltaImaginary)+deltaReal*deltaReal-deltaImaginary*deltaImaginary+dx,
+			2*(referenceReal*deltaImaginary+referenceImaginary*deltaReal)+2*deltaReal*deltaImaginary+dy
+		m++
+
+		realComponent = orbit.Real[m] + deltaReal
+		imaginaryComponent = orbit.Imaginary[m] + deltaImaginary
+		modulusSquared = realComponent*realComponent + imaginaryComponent*imaginaryComponent
+		if modulusSquared > bailoutSquared {
+			return i, modulusSquared, math.Hypot(derivativeReal, derivativeImaginary)
+		}
+
+		if modulusSquared < deltaReal*deltaReal+deltaImaginary*deltaImaginary || m == last {
+			deltaReal, deltaImaginary = realComponent, imaginaryComponent
+			m = 0
+		}
+	}
+
+	return maxIterations, modulusSquared, 0
+}
+
+// valid reports whether the orbit can be used as a reference, it must start at
+// Z(0) = 0, contain at least Z(1) and skip iterations within the orbit.
+func (o *ReferenceOrbit) valid() bool {
+	return o != nil && len(o.Real) >= 2 && len(o.Real) == len(o.Imaginary) && o.Real[0] == 0 && o.Imaginary[0] == 0 &&
+		o.SkippedIterations >= 0 && o.SkippedIterations < len(o.Real)-1
+}
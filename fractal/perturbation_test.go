@@ -0,0 +1,97 @@
+package fractal
+
+import "testing"
+
+// agreement returns the fraction of the pixels of a grid of the viewport
+// where iterate escapes at the same iteration as IteratePrecise.
+func agreement(viewport Viewport, iterate func(x int32, y int32) float64) float64 {
+	matches, total := 0, 0
+	for x := int32(0); x < viewport.Width; x += 4 {
+		for y := int32(0); y < viewport.Height; y += 4 {
+			cx, cy := viewport.PreciseCoordinates(x, y)
+			want, _ := IteratePrecise(cx, cy, viewport.MaxIterations, viewport.Bailout())
+			if iterate(x, y) == want {
+				matches++
+			}
+			total++
+		}
+	}
+	return float64(matches) / float64(total)
+}
+
+func TestIteratePerturbation(t *testing.T) {
+	tests := []struct {
+		name          string
+		center        [2]string
+		magnification float64
+	}{
+		{"shallow, reference escapes", [2]string{"0.3", "0.5"}, 400},
+		{"shallow, reference inside", [2]string{"-0.5", "0"}, 400},
+		{"deep", [2]string{"-0.743643887037158704752191506114774", "0.131825904205311970493132056385139"}, 1e15},
+	}
+
+	for _, test := range tests {
+		viewport := Viewport{Width: 64, Height: 48, MagnificationFactor: NewFloat(test.magnification), MaxIterations: 1000}
+		x, _ := ParseFloat(test.center[0])
+		y, _ := ParseFloat(test.center[1])
+		viewport.SetCenter(x, y)
+		orbit := NewReferenceOrbit(viewport)
+
+		rate := agreement(viewport, func(x int32, y int32) float64 {
+			dx := (float64(x) - float64(viewport.Width)/2) / test.magnification
+			dy := (float64(y) - float64(viewport.Height)/2) / test.magnification
+			i, _ := IteratePerturbation(orbit, dx, dy, viewport.MaxIterations, viewport.Bailout())
+			return i
+		})
+		if rate < 0.98 {
+			t.Errorf("%s: perturbation matches %.1f%% of the pixels, want at least 98%%", test.name, rate*100)
+		}
+	}
+}
+
+func TestSeriesApproximationSkipsIterations(t *testing.T) {
+	viewport := Viewport{Width: 64, Height: 48, MagnificationFactor: NewFloat(1e25), MaxIterations: 20000}
+	x, _ := ParseFloat("-0.743643887037158704752191506114774")
+	y, _ := ParseFloat("0.131825904205311970493132056385139")
+	viewport.SetCenter(x, y)
+
+	orbit := NewReferenceOrbit(viewport)
+	if orbit.SkippedIterations == 0 {
+		t.Fatal("series approximation didn't skip any iteration")
+	}
+
+	// Without the series approximation every pixel starts at dz(1) = dc
+	full := &ReferenceOrbit{Real: orbit.Real, Imaginary: orbit.Imaginary, SeriesA: 1}
+	for px := int32(0); px < viewport.Width; px += 7 {
+		for py := int32(0); py < viewport.Height; py += 7 {
+			dx := (float64(px) - float64(viewport.Width)/2) / 1e25
+			dy := (float64(py) - float64(viewport.Height)/2) / 1e25
+			got, _ := IteratePerturbation(orbit, dx, dy, viewport.MaxIterations, viewport.Bailout())
+			want, _ := IteratePerturbation(full, dx, dy, viewport.MaxIterations, viewport.Bailout())
+			if got != want {
+				t.Errorf("pixel (%d, %d) escaped at iteration %v skipping %d iterations, want %v", px, py, got, orbit.SkippedIterations, want)
+			}
+		}
+	}
+}
+
+func TestPerturbationStopsBeforeUnderflow(t *testing.T) {
+	for _, test := range []struct {
+		magnification string
+		perturbation  bool
+	}{
+		{"1e200", true},
+		{"1e300", true},
+		{"1e320", false},
+		{"1e1000", false},
+	} {
+		magnification, _ := ParseFloat(test.magnification)
+		viewport := Viewport{Width: 64, Height: 48, MagnificationFactor: magnification, MaxIterations: 100, PanX: NewFloat(0.5), PanY: NewFloat(0)}
+		if got := viewport.UsesPerturbation(); got != test.perturbation {
+			t.Errorf("UsesPerturbation at %s = %v, want %v", test.magnification, got, test.perturbation)
+		}
+		if f := newFrame(viewport); f.perturbation == f.precise {
+			t.Errorf("frame at %s iterated with perturbation %v and arbitrary precision %v", test.magnification, f.perturbation, f.precise)
+		}
+	}
+}
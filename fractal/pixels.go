@@ -0,0 +1,53 @@
+package fractal
+
+import "image"
+
+// EncodeRGB packs the pixels of the region row by row as RGB triplets, the
+// format used to send calculated regions from slave nodes to the master.
+func EncodeRGB(img *image.RGBA, region Region) []byte {
+	rgbBuffer := make([]byte, 0, region.Width()*region.Height()*3)
+	for y := region.YStart; y <= region.YEnd; y++ {
+		for x := region.XStart; x <= region.XEnd; x++ {
+			offset := img.PixOffset(int(x), int(y))
+			rgbBuffer = append(rgbBuffer, img.Pix[offset], img.Pix[offset+1], img.Pix[offset+2])
+		}
+	}
+	return rgbBuffer
+}
+
+// DecodeRGB writes the RGB triplets packed by EncodeRGB back into the region
+// of img.
+func DecodeRGB(rgbBuffer []byte, img *image.RGBA, region Region) {
+	i := 0
+	for y := region.YStart; y <= region.YEnd; y++ {
+		for x := region.XStart; x <= region.XEnd && i+2 < len(rgbBuffer); x++ {
+			offset := img.PixOffset(int(x), int(y))
+			img.Pix[offset] = rgbBuffer[i]
+			img.Pix[offset+1] = rgbBuffer[i+1]
+			img.Pix[offset+2] = rgbBuffer[i+2]
+			img.Pix[offset+3] = 255
+			i += 3
+		}
+	}
+}
+
+// DecodeIterations writes the row-major iteration counts, |z| and distances
+// to the set of the samples of the pixels of a region, as returned by the
+// slave nodes, into the region of the buffer. The distances are optional.
+func DecodeIterations(iterations []float32, modulus []float32, distance []float32, buffer *IterationBuffer, region Region) {
+	rowLength := int(region.Width() * buffer.Samples)
+	i := 0
+	for y := region.YStart; y <= region.YEnd && i+rowLength <= len(iterations) && i+rowLength <= len(modulus); y++ {
+		index := buffer.index(region.XStart, y, 0)
+		copy(buffer.Iterations[index:], iterations[i:i+rowLength])
+		copy(buffer.Modulus[index:], modulus[i:i+rowLength])
+		if i+rowLength <= len(distance) {
+			copy(buffer.Distance[index:], distance[i:i+rowLength])
+		} else {
+			for k := index; k < index+int32(rowLength); k++ {
+				buffer.Distance[k] = 0
+			}
+		}
+		i += rowLength
+	}
+}
@@ -0,0 +1,45 @@
+package fractal
+
+import "image"
+
+// Region is a rectangular block of pixels of a frame. Start and end
+// coordinates are inclusive.
+type Region struct {
+	XStart int32
+	YStart int32
+	XEnd   int32
+	YEnd   int32
+}
+
+func (r Region) Width() int32 {
+	return r.XEnd - r.XStart + 1
+}
+
+func (r Region) Height() int32 {
+	return r.YEnd - r.YStart + 1
+}
+
+// Rect returns the region as an image rectangle.
+func (r Region) Rect() image.Rectangle {
+	return image.Rect(int(r.XStart), int(r.YStart), int(r.XEnd)+1, int(r.YEnd)+1)
+}
+
+// Tiles divides the region in tiles of size x size pixels, row by row. The
+// tiles of the right and bottom edges are smaller when the size doesn't
+// divide the region.
+func (r Region) Tiles(size int32) []Region {
+	tiles := make([]Region, 0, ((r.Width()+size-1)/size)*((r.Height()+size-1)/size))
+	for y := r.YStart; y <= r.YEnd; y += size {
+		for x := r.XStart; x <= r.XEnd; x += size {
+			tile := Region{XStart: x, YStart: y, XEnd: x + size - 1, YEnd: y + size - 1}
+			if tile.XEnd > r.XEnd {
+				tile.XEnd = r.XEnd
+			}
+			if tile.YEnd > r.YEnd {
+				tile.YEnd = r.YEnd
+			}
+			tiles = append(tiles, tile)
+		}
+	}
+	return tiles
+}
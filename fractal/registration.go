@@ -0,0 +1,217 @@
+package fractal
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"mandelbrot-fractal/proto"
+)
+
+// SlaveHeartbeatInterval is the time between the heartbeats of the slave
+// nodes registered with the master node. A slave node leaves the cluster
+// when no heartbeat arrives within SlaveHeartbeatTimeout.
+const (
+	SlaveHeartbeatInterval = 2 * time.Second
+	SlaveHeartbeatTimeout  = 3 * SlaveHeartbeatInterval
+)
+
+// registration is a slave node registered with the master node.
+type registration struct {
+	ip            string
+	port          int32
+	cores         int32
+	lastHeartbeat time.Time
+}
+
+// ListenForSlaves serves the registration of slave nodes on the given port in
+// the background. The slave nodes registered join the cluster in the next
+// frame and leave it when they unregister or their heartbeats stop.
+func (c *Cluster) ListenForSlaves(port int32) error {
+	lis, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", port))
+	if err != nil {
+		return fmt.Errorf("failed to listen: %v", err)
+	}
+
+	grpcServer := grpc.NewServer()
+	proto.RegisterMandelbrotMasterNodeServer(grpcServer, &masterNodeServer{cluster: c})
+	go grpcServer.Serve(lis)
+	return nil
+}
+
+// register adds or refreshes the registration of a slave node.
+func (c *Cluster) register(ip string, port int32, cores int32) {
+	c.registrationsMutex.Lock()
+	defer c.registrationsMutex.Unlock()
+	c.registrations[net.JoinHostPort(ip, strconv.Itoa(int(port)))] = registration{ip: ip, port: port, cores: cores, lastHeartbeat: time.Now()}
+}
+
+// heartbeat refreshes the registration of a slave node, it returns false when
+// the slave node isn't registered.
+func (c *Cluster) heartbeat(ip string, port int32) bool {
+	c.registrationsMutex.Lock()
+	defer c.registrationsMutex.Unlock()
+	address := net.JoinHostPort(ip, strconv.Itoa(int(port)))
+	r, ok := c.registrations[address]
+	if ok {
+		r.lastHeartbeat = time.Now()
+		c.registrations[address] = r
+	}
+	return ok
+}
+
+func (c *Cluster) unregister(ip string, port int32) {
+	c.registrationsMutex.Lock()
+	defer c.registrationsMutex.Unlock()
+	delete(c.registrations, net.JoinHostPort(ip, strconv.Itoa(int(port))))
+}
+
+// updateMembership adds the slave nodes registered since the last frame and
+// removes the ones that unregistered or stopped sending heartbeats.
+func (c *Cluster) updateMembership() {
+	c.registrationsMutex.Lock()
+	defer c.registrationsMutex.Unlock()
+
+	now := time.Now()
+	var addresses []string
+	for address, r := range c.registrations {
+		if now.Sub(r.lastHeartbeat) > SlaveHeartbeatTimeout {
+			delete(c.registrations, address)
+			continue
+		}
+		addresses = append(addresses, address)
+	}
+
+	for i := c.SlavesCount - 1; i >= 0; i-- {
+		if _, ok := c.registrations[c.SlaveAddress(i)]; c.slavesRegistered[i] && !ok {
+			c.removeSlave(i)
+		}
+	}
+
+	sort.Strings(addresses)
+	for _, address := range addresses {
+		r := c.registrations[address]
+		if i := c.slaveIndex(address); i >= 0 {
+			c.SlavesCores[i] = r.cores
+			continue
+		}
+		if err := c.addSlave(r.ip, r.port, r.cores, true); err != nil {
+			delete(c.registrations, address)
+		}
+	}
+}
+
+// slaveIndex returns the index of the slave node at address, -1 when it isn't
+// in the cluster.
+func (c *Cluster) slaveIndex(address string) int32 {
+	for i := int32(0); i < c.SlavesCount; i++ {
+		if c.SlaveAddress(i) == address {
+			return i
+		}
+	}
+	return -1
+}
+
+// masterNodeServer serves the registration of the slave nodes.
+type masterNodeServer struct {
+	proto.UnimplementedMandelbrotMasterNodeServer
+	cluster *Cluster
+}
+
+func (s *masterNodeServer) Register(ctx context.Context, request *proto.RegisterRequest) (*proto.RegisterResponse, error) {
+	ip, err := slaveIP(ctx, request.GetAddress(), request.GetPort())
+	if err != nil {
+		return nil, err
+	}
+	s.cluster.register(ip, request.GetPort(), request.GetCores())
+	return &proto.RegisterResponse{HeartbeatInterval: int64(SlaveHeartbeatInterval)}, nil
+}
+
+func (s *masterNodeServer) Heartbeat(ctx context.Context, request *proto.HeartbeatRequest) (*proto.HeartbeatResponse, error) {
+	ip, err := slaveIP(ctx, request.GetAddress(), request.GetPort())
+	if err != nil {
+		return nil, err
+	}
+	return &proto.HeartbeatResponse{Registered: s.cluster.heartbeat(ip, request.GetPort())}, nil
+}
+
+func (s *masterNodeServer) Unregister(ctx context.Context, request *proto.HeartbeatRequest) (*proto.HeartbeatResponse, error) {
+	ip, err := slaveIP(ctx, request.GetAddress(), request.GetPort())
+	if err != nil {
+		return nil, err
+	}
+	s.cluster.unregister(ip, request.GetPort())
+	return &proto.HeartbeatResponse{}, nil
+}
+
+// slaveIP returns the address of a slave node, the one its request comes
+// from when it doesn't send one.
+func slaveIP(ctx context.Context, address string, port int32) (string, error) {
+	if port <= 0 || port > 65535 {
+		return "", status.Errorf(codes.InvalidArgument, "invalid port %d", port)
+	}
+	if len(address) > 0 {
+		return address, nil
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", status.Errorf(codes.InvalidArgument, "unknown slave node address")
+	}
+	ip, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return "", status.Errorf(codes.InvalidArgument, "unknown slave node address: %v", err)
+	}
+	return ip, nil
+}
+
+// RegisterWithMasterNode registers the slave node serving regions at address
+// and port (the address the master node sees when empty) with the master
+// node at masterAddress (IP:port), and sends heartbeats until ctx is done,
+// registering again whenever the master node doesn't know it. Then it leaves
+// the cluster.
+func RegisterWithMasterNode(ctx context.Context, masterAddress string, address string, port int32, cores int32) error {
+	conn, err := grpc.Dial(masterAddress, grpc.WithInsecure())
+	if err != nil {
+		return fmt.Errorf("cannot connect to master node at %s: %v", masterAddress, err)
+	}
+	defer conn.Close()
+	client := proto.NewMandelbrotMasterNodeClient(conn)
+
+	registered := false
+	interval := SlaveHeartbeatInterval
+	for {
+		callCtx, cancel := context.WithTimeout(ctx, interval)
+		if registered {
+			response, err := client.Heartbeat(callCtx, &proto.HeartbeatRequest{Address: address, Port: port})
+			registered = err == nil && response.GetRegistered()
+		} else {
+			response, err := client.Register(callCtx, &proto.RegisterRequest{Address: address, Port: port, Cores: cores})
+			if err == nil {
+				registered = true
+				if response.GetHeartbeatInterval() > 0 {
+					interval = time.Duration(response.GetHeartbeatInterval())
+				}
+			}
+		}
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			if registered {
+				leaveCtx, cancel := context.WithTimeout(context.Background(), interval)
+				defer cancel()
+				client.Unregister(leaveCtx, &proto.HeartbeatRequest{Address: address, Port: port})
+			}
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package fractal
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"testing"
+	"time"
+
+	"mandelbrot-fractal/proto"
+)
+
+func TestSlavesJoinAndLeave(t *testing.T) {
+	port, server := startTestSlave(t)
+	defer server.Stop()
+
+	cluster, err := NewCluster(4, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	master := &masterNodeServer{cluster: cluster}
+
+	viewport := Viewport{Width: 160, Height: 90, MagnificationFactor: NewFloat(50), MaxIterations: 100, PanX: NewFloat(2), PanY: NewFloat(0.9)}
+	want := image.NewRGBA(viewport.Bounds().Rect())
+	NewRenderer(4).CalculateRegionLocally(viewport, want, nil, viewport.Bounds())
+	render := func(slaves int32) {
+		img := image.NewRGBA(viewport.Bounds().Rect())
+		cluster.Render(viewport, img)
+		if cluster.SlavesCount != slaves || len(cluster.NodesTiles) != int(slaves)+1 {
+			t.Fatalf("%d slaves and %d nodes, want %d slaves", cluster.SlavesCount, len(cluster.NodesTiles), slaves)
+		}
+		if !bytes.Equal(img.Pix, want.Pix) {
+			t.Errorf("frame with %d slaves differs from the frame rendered locally", slaves)
+		}
+	}
+
+	ctx := context.Background()
+	if _, err := master.Register(ctx, &proto.RegisterRequest{Address: "127.0.0.1", Port: port, Cores: 8}); err != nil {
+		t.Fatal(err)
+	}
+	render(1)
+	if cluster.SlavesCores[0] != 8 || !cluster.SlavesStates[0].Healthy {
+		t.Errorf("slave with %d cores and %v, want 8 cores and healthy", cluster.SlavesCores[0], cluster.SlavesStates[0])
+	}
+
+	if response, _ := master.Heartbeat(ctx, &proto.HeartbeatRequest{Address: "127.0.0.1", Port: port}); !response.GetRegistered() {
+		t.Errorf("heartbeat of a registered slave answered unregistered")
+	}
+	if response, _ := master.Heartbeat(ctx, &proto.HeartbeatRequest{Address: "127.0.0.1", Port: port + 1}); response.GetRegistered() {
+		t.Errorf("heartbeat of an unknown slave answered registered")
+	}
+
+	if _, err := master.Unregister(ctx, &proto.HeartbeatRequest{Address: "127.0.0.1", Port: port}); err != nil {
+		t.Fatal(err)
+	}
+	render(0)
+
+	// Slaves without heartbeats leave the cluster
+	master.Register(ctx, &proto.RegisterRequest{Address: "127.0.0.1", Port: port, Cores: 8})
+	render(1)
+	for address, r := range cluster.registrations {
+		r.lastHeartbeat = time.Now().Add(-SlaveHeartbeatTimeout - time.Second)
+		cluster.registrations[address] = r
+	}
+	render(0)
+}
+
+func TestRegisterRejectsInvalidPort(t *testing.T) {
+	cluster, err := NewCluster(4, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	master := &masterNodeServer{cluster: cluster}
+	if _, err := master.Register(context.Background(), &proto.RegisterRequest{Address: "127.0.0.1", Port: 0}); err == nil {
+		t.Errorf("registered a slave without port")
+	}
+}
@@ -0,0 +1,291 @@
+package fractal
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// Renderer calculates regions of the Mandelbrot set splitting the work
+// between several threads of the local computer.
+type Renderer struct {
+	MaxLocalThreads          int32
+	LocalThreadsProcessTimes []time.Duration
+	ThreadWaitGroup          sync.WaitGroup
+}
+
+func NewRenderer(maxLocalThreads int32) *Renderer {
+	return &Renderer{
+		MaxLocalThreads:          maxLocalThreads,
+		LocalThreadsProcessTimes: make([]time.Duration, maxLocalThreads),
+	}
+}
+
+// CalculateRegionLocally renders the region of the viewport into img. The
+// image must contain the region, it may be the whole frame or only the region.
+// The iteration counts of the pixels are also stored in iterations, which
+// must contain the region, unless it is nil. The threads take small tiles of
+// the region one after another, so they all keep busy until the end.
+//
+// The histogram coloring spreads the colors with the distribution of the
+// region, the cluster recolors the frame with the distribution of all of it.
+func (r *Renderer) CalculateRegionLocally(viewport Viewport, img *image.RGBA, iterations *IterationBuffer, region Region) {
+	r.CalculateRegionContext(context.Background(), viewport, img, iterations, region)
+}
+
+// CalculateRegionContext is CalculateRegionLocally stopping when ctx is done,
+// which returns ctx.Err() leaving the region partially calculated.
+func (r *Renderer) CalculateRegionContext(ctx context.Context, viewport Viewport, img *image.RGBA, iterations *IterationBuffer, region Region) error {
+	f := newFrame(viewport)
+	if iterations == nil && viewport.Coloring == HistogramColoring {
+		iterations = NewIterationBuffer(region, viewport.Samples())
+	}
+	if iterations != nil {
+		iterations.MaxIterations = viewport.MaxIterations
+		iterations.EstimatedDistance = f.distance
+	}
+	queue := newTileQueue(region.Tiles(LocalTileSize))
+	r.calculateTiles(ctx, f, img, iterations, queue, nil)
+	if !queue.complete() {
+		return ctx.Err()
+	}
+
+	if viewport.Coloring == HistogramColoring {
+		iterations.Recolor(viewport, iterations.Histogram(region, viewport.MaxIterations), img, region)
+	}
+	return nil
+}
+
+// calculateTiles calculates the tiles of the queue in MaxLocalThreads
+// threads, calling calculated (when not nil) after every tile. When ctx is
+// done the queue is cancelled, leaving the tiles not calculated.
+func (r *Renderer) calculateTiles(ctx context.Context, f *frame, img *image.RGBA, iterations *IterationBuffer, queue *tileQueue, calculated func(tile Region)) {
+	for i := int32(0); i < r.MaxLocalThreads; i++ {
+		r.ThreadWaitGroup.Add(1)
+		go r.calculateTilesInThread(ctx, i, f, img, iterations, queue, calculated)
+	}
+
+	r.ThreadWaitGroup.Wait()
+}
+
+// calculateTilesInThread takes tiles from the queue until every tile is
+// calculated. The process time of the thread only counts the time spent
+// calculating tiles.
+func (r *Renderer) calculateTilesInThread(ctx context.Context, threadIndex int32, f *frame, img *image.RGBA, iterations *IterationBuffer, queue *tileQueue, calculated func(tile Region)) {
+	defer r.ThreadWaitGroup.Done()
+
+	var processTime time.Duration
+	for {
+		tile, ok := queue.take()
+		if !ok {
+			break
+		}
+		start := time.Now()
+		finished := r.calculateFragment(ctx, f, img, iterations, tile)
+		processTime += time.Since(start)
+		if !finished {
+			queue.cancel()
+			break
+		}
+		if calculated != nil {
+			calculated(tile)
+		}
+		queue.done()
+	}
+	r.LocalThreadsProcessTimes[threadIndex] = processTime
+}
+
+// calculateFragment calculates the pixels of the fragment, it returns false
+// when ctx is done before all of them are calculated.
+func (r *Renderer) calculateFragment(ctx context.Context, f *frame, img *image.RGBA, iterations *IterationBuffer, fragment Region) bool {
+	done := ctx.Done()
+	samples := f.Samples()
+	for x := fragment.XStart; x <= fragment.XEnd; x++ {
+		for y := fragment.YStart; y <= fragment.YEnd; y++ {
+			select {
+			case <-done:
+				return false
+			default:
+			}
+
+			// Supersampling: the color of the pixel is the average of its samples
+			var average colorAverage
+			for sample := int32(0); sample < samples; sample++ {
+				offsetX, offsetY := f.sampleOffset(sample)
+				i, modulus, distance := f.pixelIterations(float64(x)+offsetX, float64(y)+offsetY)
+				if iterations != nil {
+					iterations.Set(x, y, sample, i, modulus, distance)
+				}
+				average.add(f.PixelColor(i, modulus, distance, f.formula.Degree()))
+			}
+			img.SetRGBA(int(x), int(y), average.color())
+		}
+	}
+	return true
+}
+
+// frame holds the parameters of a viewport converted once per frame to the
+// representation used by the iteration kernels.
+type frame struct {
+	Viewport
+	bailout       float64
+	precise       bool // iterate with arbitrary precision
+	perturbation  bool // iterate deltas from the reference orbit
+	distance      bool // track the derivative of the orbits to estimate the distance to the set
+	formula       Formula
+	magnification float64
+	panX          float64
+	panY          float64
+}
+
+func newFrame(viewport Viewport) *frame {
+	f := &frame{Viewport: viewport, bailout: viewport.Bailout(), perturbation: viewport.UsesPerturbation()}
+	f.precise = viewport.NeedsArbitraryPrecision() && viewport.IsMandelbrot() && !f.perturbation
+	f.formula = viewport.IteratedFormula()
+	f.distance = viewport.Coloring.UsesDistance()
+	f.magnification, _ = viewport.MagnificationFactor.Float64()
+	f.panX, _ = viewport.PanX.Float64()
+	f.panY, _ = viewport.PanY.Float64()
+	if f.perturbation && !f.ReferenceOrbit.valid() {
+		f.ReferenceOrbit = NewReferenceOrbit(viewport)
+	}
+	return f
+}
+
+// pixelIterations returns the iteration at which the orbit of the point at
+// position (x, y) of the frame, in pixels, escaped, its |z| at that iteration
+// and the estimated distance to the set in pixels (0 when it isn't
+// estimated), the max iterations when the point belongs to the set.
+func (f *frame) pixelIterations(x float64, y float64) (float64, float64, float64) {
+	var i, modulusSquared, derivative float64
+	if f.perturbation {
+		// Distance to the center of the frame, the reference point of the orbit
+		dx := (x - float64(f.Width)/2) / f.magnification
+		dy := (y - float64(f.Height)/2) / f.magnification
+		if f.distance {
+			i, modulusSquared, derivative = IteratePerturbationDistance(f.ReferenceOrbit, dx, dy, f.MaxIterations, f.bailout)
+		} else {
+			i, modulusSquared = IteratePerturbation(f.ReferenceOrbit, dx, dy, f.MaxIterations, f.bailout)
+		}
+	} else if f.precise {
+		cx, cy := f.preciseCoordinates(x, y)
+		if f.distance {
+			i, modulusSquared, derivative = IteratePreciseDistance(cx, cy, f.MaxIterations, f.bailout)
+		} else {
+			i, modulusSquared = IteratePrecise(cx, cy, f.MaxIterations, f.bailout)
+		}
+	} else if f.distance {
+		i, modulusSquared, derivative = IterateDistance(f.formula, (x/f.magnification)-f.panX, (y/f.magnification)-f.panY, f.MaxIterations, f.bailout)
+	} else if _, ok := f.formula.(Mandelbrot); ok {
+		i, modulusSquared = Iterate((x/f.magnification)-f.panX, (y/f.magnification)-f.panY, f.MaxIterations, f.bailout)
+	} else {
+		i, modulusSquared = IterateFormula(f.formula, (x/f.magnification)-f.panX, (y/f.magnification)-f.panY, f.MaxIterations, f.bailout)
+	}
+
+	// Beyond the float64 range the magnification is +Inf, the distance is
+	// only scaled when there is one
+	distance := DistanceEstimate(modulusSquared, derivative)
+	if distance > 0 {
+		distance *= f.magnification
+	}
+	return i, math.Sqrt(modulusSquared), distance
+}
+
+// GetPixelColorAtPosition returns the color of the pixel at position (x, y)
+// of the viewport.
+func GetPixelColorAtPosition(viewport Viewport, x int32, y int32) color.RGBA {
+	f := newFrame(viewport)
+	i, modulus, distance := f.pixelIterations(float64(x), float64(y))
+	return viewport.PixelColor(i, modulus, distance, f.formula.Degree())
+}
+
+// Iterate returns the iteration at which the orbit of the point (x, y) leaves
+// the circle of radius bailoutRadius and the squared modulus of z at that
+// iteration, or maxIterations when the point belongs to the set.
+//
+// The points of the main cardioid and the period-2 bulb aren't iterated, and
+// the iteration of the rest of points of the set stops once their orbit
+// falls into a cycle.
+func Iterate(x float64, y float64, maxIterations float64, bailoutRadius float64) (float64, float64) {
+	if InsideMainBulbs(x, y) {
+		return maxIterations, 0
+	}
+
+	realComponent := x
+	imaginaryComponent := y
+	bailoutSquared := bailoutRadius * bailoutRadius
+	periodicity := newPeriodicityCheck(realComponent, imaginaryComponent)
+	var tempRealComponent, modulusSquared float64
+
+	for i := float64(0); i < maxIterations; i++ {
+		tempRealComponent = (realComponent * realComponent) - (imaginaryComponent * imaginaryComponent) + x
+		imaginaryComponent = 2*realComponent*imaginaryComponent + y
+		realComponent = tempRealComponent
+
+		modulusSquared = realComponent*realComponent + imaginaryComponent*imaginaryComponent
+		if modulusSquared > bailoutSquared {
+			return i, modulusSquared
+		}
+
+		if periodicity.cycled(realComponent, imaginaryComponent) {
+			return maxIterations, modulusSquared
+		}
+	}
+
+	return maxIterations, modulusSquared
+}
+
+// IteratePrecise is the arbitrary precision version of Iterate, the orbit is
+// calculated with the precision of x.
+func IteratePrecise(x *big.Float, y *big.Float, maxIterations float64, bailoutRadius float64) (float64, float64) {
+	i, modulusSquared, _ := iteratePrecise(x, y, maxIterations, bailoutRadius, false)
+	return i, modulusSquared
+}
+
+// IteratePreciseDistance is the version of IteratePrecise that also returns
+// the modulus of the derivative dz/dc of the orbit at the iteration it
+// escaped, 0 when the point belongs to the set. The derivative doesn't need
+// more precision than float64.
+func IteratePreciseDistance(x *big.Float, y *big.Float, maxIterations float64, bailoutRadius float64) (float64, float64, float64) {
+	return iteratePrecise(x, y, maxIterations, bailoutRadius, true)
+}
+
+func iteratePrecise(x *big.Float, y *big.Float, maxIterations float64, bailoutRadius float64, trackDerivative bool) (float64, float64, float64) {
+	precision := x.Prec()
+	realComponent := new(big.Float).SetPrec(precision).Set(x)
+	imaginaryComponent := new(big.Float).SetPrec(precision).Set(y)
+	realSquared := new(big.Float).SetPrec(precision)
+	imaginarySquared := new(big.Float).SetPrec(precision)
+	bailoutSquared := bailoutRadius * bailoutRadius
+	var modulusSquared float64
+	realFloat, _ := x.Float64()
+	imaginaryFloat, _ := y.Float64()
+	derivativeReal, derivativeImaginary := 1.0, 0.0
+
+	for i := float64(0); i < maxIterations; i++ {
+		if trackDerivative {
+			derivativeReal, derivativeImaginary = Mandelbrot{}.Derivative(realFloat, imaginaryFloat, derivativeReal, derivativeImaginary)
+		}
+
+		realSquared.Mul(realComponent, realComponent)
+		imaginarySquared.Mul(imaginaryComponent, imaginaryComponent)
+
+		imaginaryComponent.Mul(imaginaryComponent, realComponent)
+		imaginaryComponent.Add(imaginaryComponent, imaginaryComponent).Add(imaginaryComponent, y)
+		realComponent.Sub(realSquared, imaginarySquared).Add(realComponent, x)
+
+		// The escape test doesn't need more precision than float64
+		realFloat, _ = realComponent.Float64()
+		imaginaryFloat, _ = imaginaryComponent.Float64()
+		modulusSquared = realFloat*realFloat + imaginaryFloat*imaginaryFloat
+		if modulusSquared > bailoutSquared {
+			return i, modulusSquared, math.Hypot(derivativeReal, derivativeImaginary)
+		}
+	}
+
+	return maxIterations, modulusSquared, 0
+}
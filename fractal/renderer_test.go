@@ -0,0 +1,189 @@
+package fractal
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"testing"
+)
+
+func TestIterateInsideSet(t *testing.T) {
+	points := []struct{ x, y float64 }{
+		{0, 0},
+		{-1, 0},
+		{-2, 0},
+		{0.25, 0},
+		{-0.5, 0.5},
+		{-1.1, 0.1},
+		{0, 1},
+	}
+
+	for _, p := range points {
+		if i, _ := Iterate(p.x, p.y, 1000, DefaultBailoutRadius); i != 1000 {
+			t.Errorf("Iterate(%v, %v) escaped at iteration %v, want point inside the set", p.x, p.y, i)
+		}
+	}
+}
+
+func TestIterateOutsideSet(t *testing.T) {
+	points := []struct {
+		x, y float64
+		i    float64
+	}{
+		{1, 0, 1}, // 1, 2, 5
+		{0.5, 0.5, 3},
+		{-1.5, -1.5, 0}, // negative real*imaginary products must escape too
+		{0, -2, 0},
+		{-2.1, 0, 0},
+		{0.26, 0, 28}, // just outside the cusp of the main cardioid
+	}
+
+	for _, p := range points {
+		i, modulusSquared := Iterate(p.x, p.y, 1000, DefaultBailoutRadius)
+		if i != p.i {
+			t.Errorf("Iterate(%v, %v) escaped at iteration %v, want %v", p.x, p.y, i, p.i)
+		}
+		if modulusSquared <= DefaultBailoutRadius*DefaultBailoutRadius {
+			t.Errorf("Iterate(%v, %v) escaped with |z|² %v, want more than %v", p.x, p.y, modulusSquared, DefaultBailoutRadius*DefaultBailoutRadius)
+		}
+	}
+}
+
+func TestIterateBailoutRadius(t *testing.T) {
+	i, _ := Iterate(1, 0, 1000, DefaultBailoutRadius)
+	j, modulusSquared := Iterate(1, 0, 1000, 100)
+	if j <= i {
+		t.Errorf("escaped at iteration %v with radius 100, want later than %v", j, i)
+	}
+	if modulusSquared <= 100*100 {
+		t.Errorf("escaped with |z|² %v, want more than %v", modulusSquared, 100*100)
+	}
+}
+
+func TestCalculateRegionRequestCarriesViewport(t *testing.T) {
+	panX, _ := ParseFloat("1.40114118676012541203124958612938402761")
+	viewport := Viewport{Width: 640, Height: 480, MagnificationFactor: NewFloat(2e30), MaxIterations: 120, PanX: panX, PanY: NewFloat(0.6), Coloring: SmoothColoring, BailoutRadius: 64, Formula: Julia{CReal: -0.8, CImaginary: 0.156}}
+
+	got, err := viewportFromRequest(newCalculateRegionRequest(viewport, 0, viewport.Bounds(), IterationsResponse, false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.MagnificationFactor.Cmp(viewport.MagnificationFactor) != 0 || got.PanX.Cmp(viewport.PanX) != 0 || got.PanY.Cmp(viewport.PanY) != 0 {
+		t.Errorf("slave viewport at (%v, %v) x%v, want (%v, %v) x%v", got.PanX, got.PanY, got.MagnificationFactor, viewport.PanX, viewport.PanY, viewport.MagnificationFactor)
+	}
+
+	got.MagnificationFactor, got.PanX, got.PanY = viewport.MagnificationFactor, viewport.PanX, viewport.PanY
+	if got != viewport {
+		t.Errorf("slave viewport %+v, want %+v", got, viewport)
+	}
+}
+
+func TestIteratePreciseMatchesIterate(t *testing.T) {
+	points := []struct{ x, y float64 }{{1, 0}, {0.26, 0}, {-0.75, 0.1}, {-0.5, 0.5}, {-1.5, -1.5}}
+
+	for _, p := range points {
+		i, _ := Iterate(p.x, p.y, 500, DefaultBailoutRadius)
+		j, _ := IteratePrecise(NewFloat(p.x), NewFloat(p.y), 500, DefaultBailoutRadius)
+		if i != j {
+			t.Errorf("IteratePrecise(%v, %v) escaped at iteration %v, want %v", p.x, p.y, j, i)
+		}
+	}
+}
+
+// BenchmarkDefaultViewport renders the initial view of the window, and
+// iterates its pixels in a single thread with Iterate and, as baseline of the
+// interior detection, with iterateNaive.
+func BenchmarkDefaultViewport(b *testing.B) {
+	viewport := Viewport{Width: 1280, Height: 720, MagnificationFactor: NewFloat(400), MaxIterations: 80, PanX: NewFloat(1.624203), PanY: NewFloat(0.620820)}
+
+	b.Run("render", func(b *testing.B) {
+		renderer := NewRenderer(16)
+		img := image.NewRGBA(viewport.Bounds().Rect())
+		for n := 0; n < b.N; n++ {
+			renderer.CalculateRegionLocally(viewport, img, nil, viewport.Bounds())
+		}
+	})
+
+	kernels := []struct {
+		name    string
+		iterate func(x float64, y float64) float64
+	}{
+		{"iterate", func(x float64, y float64) float64 {
+			i, _ := Iterate(x, y, viewport.MaxIterations, DefaultBailoutRadius)
+			return i
+		}},
+		{"iterate-naive", func(x float64, y float64) float64 {
+			return iterateNaive(x, y, viewport.MaxIterations)
+		}},
+	}
+	var points [][2]float64
+	for y := int32(0); y < viewport.Height; y++ {
+		for x := int32(0); x < viewport.Width; x++ {
+			cx, cy := viewport.Coordinates(x, y)
+			points = append(points, [2]float64{cx, cy})
+		}
+	}
+	for _, kernel := range kernels {
+		b.Run(kernel.name, func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				for _, p := range points {
+					kernel.iterate(p[0], p[1])
+				}
+			}
+		})
+	}
+}
+
+func TestSupersampling(t *testing.T) {
+	viewport := Viewport{Width: 64, Height: 36, MagnificationFactor: NewFloat(20), MaxIterations: 100, PanX: NewFloat(2), PanY: NewFloat(0.9)}
+	single := image.NewRGBA(viewport.Bounds().Rect())
+	NewRenderer(4).CalculateRegionLocally(viewport, single, nil, viewport.Bounds())
+	viewport.Supersampling = 1
+	img := image.NewRGBA(viewport.Bounds().Rect())
+	NewRenderer(4).CalculateRegionLocally(viewport, img, nil, viewport.Bounds())
+	if !bytes.Equal(img.Pix, single.Pix) {
+		t.Errorf("a single sample per side differs from no supersampling")
+	}
+
+	// Every sample is the point at its position within the pixel
+	viewport.Supersampling = 3
+	iterations := NewIterationBuffer(viewport.Bounds(), viewport.Samples())
+	NewRenderer(4).CalculateRegionLocally(viewport, img, iterations, viewport.Bounds())
+	for _, p := range []struct{ x, y int32 }{{0, 0}, {20, 17}, {45, 30}} {
+		for sample := int32(0); sample < viewport.Samples(); sample++ {
+			offsetX, offsetY := viewport.sampleOffset(sample)
+			want, _ := Iterate((float64(p.x)+offsetX)/20-2, (float64(p.y)+offsetY)/20-0.9, 100, DefaultBailoutRadius)
+			if i, _, _ := iterations.At(p.x, p.y, sample); i != want {
+				t.Errorf("sample %v of pixel (%v, %v) escaped at iteration %v, want %v", sample, p.x, p.y, i, want)
+			}
+		}
+	}
+
+	// The supersampled frame can be recolored
+	recolored := viewport
+	recolored.Coloring = SmoothColoring
+	iterations.Recolor(recolored, nil, img, viewport.Bounds())
+	want := image.NewRGBA(viewport.Bounds().Rect())
+	NewRenderer(4).CalculateRegionLocally(recolored, want, nil, viewport.Bounds())
+	if !bytes.Equal(img.Pix, want.Pix) {
+		t.Errorf("recolored supersampled frame differs from the rendered one")
+	}
+	recolored.Supersampling = 2
+	if iterations.CanRecolor(recolored) {
+		t.Errorf("CanRecolor(%v samples) = true for a buffer of %v samples", recolored.Samples(), iterations.Samples)
+	}
+}
+
+func TestCalculateRegionContextCancelled(t *testing.T) {
+	viewport := Viewport{Width: 64, Height: 36, MagnificationFactor: NewFloat(20), MaxIterations: 100, PanX: NewFloat(2), PanY: NewFloat(0.9)}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	img := image.NewRGBA(viewport.Bounds().Rect())
+	if err := NewRenderer(4).CalculateRegionContext(ctx, viewport, img, nil, viewport.Bounds()); err != context.Canceled {
+		t.Errorf("cancelled region returned %v, want %v", err, context.Canceled)
+	}
+	if err := NewRenderer(4).CalculateRegionContext(context.Background(), viewport, img, nil, viewport.Bounds()); err != nil {
+		t.Errorf("region returned %v", err)
+	}
+}
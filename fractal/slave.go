@@ -0,0 +1,343 @@
+package fractal
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"math/big"
+	"net"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"mandelbrot-fractal/proto"
+)
+
+// SlaveNodeServer calculates the regions requested by the master node.
+type SlaveNodeServer struct {
+	proto.UnimplementedMandelbrotSlaveNodeServer
+	MaxLocalThreads int32
+	FrameTimeout    time.Duration           // Time the frame of a master node is kept after its last request, DefaultFrameTimeout when 0
+	frames          map[string]*masterFrame // Frame requested by every master node, by connection
+	framesMutex     sync.Mutex
+}
+
+// DefaultFrameTimeout is the time a slave node keeps the frame of a master
+// node after its last request, so the master nodes that go away are
+// forgotten.
+const DefaultFrameTimeout = time.Minute
+
+// masterFrame is the newest frame requested by a master node. Its context is
+// cancelled when the master node requests a newer frame, and it is forgotten
+// then or once no request of it arrives within the frame timeout.
+type masterFrame struct {
+	generation uint64
+	requests   int             // Requests of the frame in flight
+	idle       *time.Timer     // Forgets the frame while no request is in flight
+	palette    *Palette        // Palette of the frame, for the requests that omit it
+	orbit      *ReferenceOrbit // Reference orbit of the frame, for the requests that omit it
+	kept       bool            // palette and orbit were received
+	ctx        context.Context
+	cancel     context.CancelFunc
+}
+
+// ProcessRequestsFromMasterNode serves the regions requested by the master
+// node on the given port. It blocks until the server stops.
+func ProcessRequestsFromMasterNode(port int32, maxLocalThreads int32) error {
+	lis, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", port))
+	if err != nil {
+		return fmt.Errorf("failed to listen: %v", err)
+	}
+
+	var opts []grpc.ServerOption
+	grpcServer := grpc.NewServer(opts...)
+	proto.RegisterMandelbrotSlaveNodeServer(grpcServer, &SlaveNodeServer{MaxLocalThreads: maxLocalThreads})
+	return grpcServer.Serve(lis)
+}
+
+func (s *SlaveNodeServer) CalculateRegion(ctx context.Context, request *proto.CalculateRegionRequest) (*proto.CalculateRegionResponse, error) {
+	region := Region{XStart: request.GetXStart(), YStart: request.GetYStart(), XEnd: request.GetXEnd(), YEnd: request.GetYEnd()}
+	if region.Width() <= 0 || region.Height() <= 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "empty region %+v", region)
+	}
+
+	ctx, cancel := s.frameContext(ctx, request.GetGeneration())
+	defer cancel()
+	viewport, kept, err := s.frameViewport(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.calculateRegion(ctx, viewport, ResponseFormat(request.GetResponseFormat()), region)
+	if err != nil {
+		return nil, err
+	}
+	response.KeepsFrame = kept
+	return response, nil
+}
+
+// CalculateTiles calculates the tiles requested one after another, sending
+// every tile to the master node as soon as it is calculated.
+func (s *SlaveNodeServer) CalculateTiles(request *proto.CalculateRegionRequest, stream proto.MandelbrotSlaveNode_CalculateTilesServer) error {
+	tiles, err := decodeTiles(request.GetTiles())
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	ctx, cancel := s.frameContext(stream.Context(), request.GetGeneration())
+	defer cancel()
+	viewport, kept, err := s.frameViewport(ctx, request)
+	if err != nil {
+		return err
+	}
+
+	for _, tile := range tiles {
+		response, err := s.calculateRegion(ctx, viewport, ResponseFormat(request.GetResponseFormat()), tile)
+		if err != nil {
+			return err
+		}
+		response.XStart, response.YStart, response.XEnd, response.YEnd = tile.XStart, tile.YStart, tile.XEnd, tile.YEnd
+		response.KeepsFrame = kept
+		if err := stream.Send(response); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// calculateRegion calculates a region of the viewport and returns it in the
+// given format, unless ctx is done before.
+func (s *SlaveNodeServer) calculateRegion(ctx context.Context, viewport Viewport, format ResponseFormat, region Region) (*proto.CalculateRegionResponse, error) {
+	// Every request gets its own renderer so concurrent requests don't share state
+	renderer := NewRenderer(s.MaxLocalThreads)
+	img := image.NewRGBA(region.Rect())
+	iterations := NewIterationBuffer(region, viewport.Samples())
+	if err := renderer.CalculateRegionContext(ctx, viewport, img, iterations, region); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+
+	localThreadsProcessTimesInt64 := make([]int64, renderer.MaxLocalThreads)
+	for i := int32(0); i < renderer.MaxLocalThreads; i++ {
+		localThreadsProcessTimesInt64[i] = renderer.LocalThreadsProcessTimes[i].Nanoseconds()
+	}
+
+	response := &proto.CalculateRegionResponse{ThreadsProcessTimes: localThreadsProcessTimesInt64}
+	if format == IterationsResponse {
+		// The master node colors the region
+		response.Iterations = iterations.Iterations
+		response.Modulus = iterations.Modulus
+		if iterations.EstimatedDistance {
+			response.Distance = iterations.Distance
+		}
+	} else {
+		response.RGBPixels = EncodeRGB(img, region)
+	}
+	return response, nil
+}
+
+// frameContext returns the context of a request of the given frame of the
+// master node of ctx, which is also done when the master node requests a
+// newer frame. So the stale requests are cancelled even when the
+// cancellation of the master node doesn't reach the slave node. The requests
+// of a frame older than the newest one in flight are cancelled right away.
+func (s *SlaveNodeServer) frameContext(ctx context.Context, generation uint64) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(ctx)
+	p, ok := peer.FromContext(ctx)
+	if !ok || generation == 0 {
+		return ctx, cancel
+	}
+
+	s.framesMutex.Lock()
+	if s.frames == nil {
+		s.frames = make(map[string]*masterFrame)
+	}
+	master := p.Addr.String()
+	frame := s.frames[master]
+	if frame == nil || frame.generation < generation {
+		if frame != nil {
+			frame.cancel()
+		}
+		frameCtx, frameCancel := context.WithCancel(context.Background())
+		frame = &masterFrame{generation: generation, ctx: frameCtx, cancel: frameCancel}
+		s.frames[master] = frame
+	}
+	if frame.generation > generation {
+		s.framesMutex.Unlock()
+		cancel()
+		return ctx, cancel
+	}
+	frame.requests++
+	if frame.idle != nil {
+		frame.idle.Stop()
+		frame.idle = nil
+	}
+	s.framesMutex.Unlock()
+
+	go func() {
+		select {
+		case <-frame.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		cancel()
+		s.releaseFrame(master, frame)
+	}
+}
+
+// releaseFrame releases a request of a frame of the master node. A frame
+// replaced by a newer one is done after its last request, the newest frame is
+// kept for the next requests of the master node for the frame timeout.
+func (s *SlaveNodeServer) releaseFrame(master string, frame *masterFrame) {
+	s.framesMutex.Lock()
+	defer s.framesMutex.Unlock()
+	frame.requests--
+	if frame.requests > 0 {
+		return
+	}
+	if s.frames[master] != frame {
+		frame.cancel()
+		return
+	}
+
+	timeout := s.FrameTimeout
+	if timeout == 0 {
+		timeout = DefaultFrameTimeout
+	}
+	frame.idle = time.AfterFunc(timeout, func() {
+		s.framesMutex.Lock()
+		defer s.framesMutex.Unlock()
+		// A request may have arrived while the timer fired
+		if frame.requests == 0 && s.frames[master] == frame {
+			frame.cancel()
+			delete(s.frames, master)
+		}
+	})
+}
+
+// frameViewport returns the viewport of a request, taken after frameContext.
+// The palette and the reference orbit of a frame, the bulk of the requests,
+// are kept with the frame of the master node for the requests that omit them;
+// it reports whether they are kept. The requests that omit them fail with
+// FailedPrecondition when the frame isn't kept, the master node sends them
+// again then.
+func (s *SlaveNodeServer) frameViewport(ctx context.Context, request *proto.CalculateRegionRequest) (Viewport, bool, error) {
+	viewport, err := viewportFromRequest(request)
+	if err != nil {
+		return Viewport{}, false, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	var frame *masterFrame
+	if p, ok := peer.FromContext(ctx); ok && request.GetGeneration() != 0 {
+		s.framesMutex.Lock()
+		defer s.framesMutex.Unlock()
+		if f := s.frames[p.Addr.String()]; f != nil && f.generation == request.GetGeneration() {
+			frame = f
+		}
+	}
+
+	if !request.GetCachedFrame() {
+		if frame == nil {
+			return viewport, false, nil
+		}
+		frame.palette, frame.orbit, frame.kept = viewport.Palette, viewport.ReferenceOrbit, true
+		return viewport, true, nil
+	}
+	if frame == nil || !frame.kept {
+		return Viewport{}, false, status.Errorf(codes.FailedPrecondition, "frame %d not kept", request.GetGeneration())
+	}
+	viewport.Palette, viewport.ReferenceOrbit = frame.palette, frame.orbit
+	return viewport, true, nil
+}
+
+// decodeTiles unpacks the tiles packed by encodeTiles.
+func decodeTiles(encoded []int32) ([]Region, error) {
+	if len(encoded) == 0 || len(encoded)%4 != 0 {
+		return nil, fmt.Errorf("invalid tiles %v", encoded)
+	}
+	tiles := make([]Region, 0, len(encoded)/4)
+	for i := 0; i < len(encoded); i += 4 {
+		tile := Region{XStart: encoded[i], YStart: encoded[i+1], XEnd: encoded[i+2], YEnd: encoded[i+3]}
+		if tile.Width() <= 0 || tile.Height() <= 0 {
+			return nil, fmt.Errorf("empty tile %+v", tile)
+		}
+		tiles = append(tiles, tile)
+	}
+	return tiles, nil
+}
+
+// viewportFromRequest returns the viewport the master node rendering the
+// region requested is using.
+func viewportFromRequest(request *proto.CalculateRegionRequest) (Viewport, error) {
+	viewport := Viewport{
+		Width:               request.GetWidth(),
+		Height:              request.GetHeight(),
+		MagnificationFactor: NewFloat(request.GetMagnificationFactor()),
+		MaxIterations:       request.GetMaxIterations(),
+		PanX:                NewFloat(request.GetPanX()),
+		PanY:                NewFloat(request.GetPanY()),
+		Coloring:            ColoringMode(request.GetColoring()),
+		BailoutRadius:       request.GetBailoutRadius(),
+		DeepZoom:            DeepZoomMode(request.GetDeepZoom()),
+		Supersampling:       request.GetSupersampling(),
+	}
+
+	if len(request.GetFormula()) > 0 {
+		formula, err := ParseFormula(request.GetFormula())
+		if err != nil {
+			return Viewport{}, err
+		}
+		viewport.Formula = formula
+	}
+
+	if len(request.GetPalette()) > 0 {
+		palette, err := ParsePalette([]byte(request.GetPalette()))
+		if err != nil {
+			return Viewport{}, err
+		}
+		viewport.Palette = palette
+	}
+
+	if len(request.GetReferenceOrbitReal()) > 0 {
+		viewport.ReferenceOrbit = &ReferenceOrbit{Real: request.GetReferenceOrbitReal(), Imaginary: request.GetReferenceOrbitImaginary(), SkippedIterations: int(request.GetSkippedIterations())}
+		if coefficients := request.GetSeriesCoefficients(); len(coefficients) == 6 {
+			viewport.ReferenceOrbit.SeriesA = complex(coefficients[0], coefficients[1])
+			viewport.ReferenceOrbit.SeriesB = complex(coefficients[2], coefficients[3])
+			viewport.ReferenceOrbit.SeriesC = complex(coefficients[4], coefficients[5])
+		} else {
+			// Without coefficients the deltas start at dz(1) = dc
+			viewport.ReferenceOrbit.SkippedIterations = 0
+			viewport.ReferenceOrbit.SeriesA = 1
+		}
+		if !viewport.ReferenceOrbit.valid() {
+			return Viewport{}, fmt.Errorf("invalid reference orbit of %d iterations", len(viewport.ReferenceOrbit.Real))
+		}
+	}
+
+	// Arbitrary precision values (exact hexadecimal mantissas) replace the
+	// float64 ones when present
+	precise := []struct {
+		text  string
+		value **big.Float
+	}{
+		{request.GetPreciseMagnificationFactor(), &viewport.MagnificationFactor},
+		{request.GetPrecisePanX(), &viewport.PanX},
+		{request.GetPrecisePanY(), &viewport.PanY},
+	}
+	for _, p := range precise {
+		if len(p.text) == 0 {
+			continue
+		}
+		value, _, err := big.ParseFloat(p.text, 0, uint(request.GetPrecision()), big.ToNearestEven)
+		if err != nil {
+			return Viewport{}, fmt.Errorf("invalid arbitrary precision value %q: %v", p.text, err)
+		}
+		*p.value = value
+	}
+
+	return viewport, nil
+}
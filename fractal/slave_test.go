@@ -0,0 +1,129 @@
+package fractal
+
+import (
+	"context"
+	"image/color"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func TestSlaveCancelsStaleFrames(t *testing.T) {
+	s := &SlaveNodeServer{MaxLocalThreads: 4}
+	master := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 40000}})
+	other := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 40001}})
+
+	first, cancelFirst := s.frameContext(master, 1)
+	defer cancelFirst()
+	same, cancelSame := s.frameContext(master, 1)
+	defer cancelSame()
+	otherMaster, cancelOther := s.frameContext(other, 1)
+	defer cancelOther()
+	if first.Err() != nil || same.Err() != nil || otherMaster.Err() != nil {
+		t.Fatalf("requests of the newest frame cancelled")
+	}
+
+	second, cancelSecond := s.frameContext(master, 2)
+	defer cancelSecond()
+	<-first.Done()
+	<-same.Done()
+	if second.Err() != nil {
+		t.Errorf("request of the newest frame cancelled")
+	}
+	if otherMaster.Err() != nil {
+		t.Errorf("request of another master node cancelled")
+	}
+
+	stale, cancelStale := s.frameContext(master, 1)
+	defer cancelStale()
+	if stale.Err() == nil {
+		t.Errorf("request of a stale frame not cancelled")
+	}
+}
+
+func TestSlaveForgetsFinishedFrames(t *testing.T) {
+	s := &SlaveNodeServer{MaxLocalThreads: 4, FrameTimeout: 50 * time.Millisecond}
+	for port := 40000; port < 40002; port++ {
+		master := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port}})
+		first, cancelFirst := s.frameContext(master, 1)
+		second, cancelSecond := s.frameContext(master, 1)
+		cancelFirst()
+		if second.Err() != nil {
+			t.Errorf("master node %d: request cancelled when another request of the frame finished", port)
+		}
+		cancelSecond()
+		if first.Err() == nil || second.Err() == nil {
+			t.Errorf("master node %d: requests not cancelled", port)
+		}
+
+		// The next request of the frame finds it between requests
+		next, cancelNext := s.frameContext(master, 1)
+		if next.Err() != nil {
+			t.Errorf("master node %d: request of the frame cancelled between requests", port)
+		}
+		cancelNext()
+	}
+
+	frames := func() int {
+		s.framesMutex.Lock()
+		defer s.framesMutex.Unlock()
+		return len(s.frames)
+	}
+	if n := frames(); n != 2 {
+		t.Errorf("%d frames kept right after the last requests, want 2", n)
+	}
+	for deadline := time.Now().Add(5 * time.Second); frames() > 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := frames(); n != 0 {
+		t.Errorf("%d frames remembered after the master nodes stopped, want 0", n)
+	}
+}
+
+func TestSlaveKeepsFrame(t *testing.T) {
+	s := &SlaveNodeServer{MaxLocalThreads: 4}
+	master := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 40000}})
+	viewport := Viewport{Width: 64, Height: 48, MagnificationFactor: NewFloat(1e20), MaxIterations: 1000, PanX: NewFloat(0.5), PanY: NewFloat(0), Palette: &Palette{Name: "test", Stops: []ColorStop{{0.5, color.RGBA{R: 255, A: 255}}}}}
+	viewport.ReferenceOrbit = NewReferenceOrbit(viewport)
+	full := newCalculateRegionRequest(viewport, 0, viewport.Bounds(), IterationsResponse, false)
+	cached := newCalculateRegionRequest(viewport, 0, viewport.Bounds(), IterationsResponse, true)
+	full.Generation, cached.Generation = 1, 1
+	if len(cached.GetReferenceOrbitReal()) > 0 || len(cached.GetPalette()) > 0 {
+		t.Fatalf("request of a cached frame sends the frame")
+	}
+
+	ctx, cancel := s.frameContext(master, 1)
+	if _, _, err := s.frameViewport(ctx, cached); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("request of a frame not kept: error %v, want FailedPrecondition", err)
+	}
+	if _, kept, err := s.frameViewport(ctx, full); err != nil || !kept {
+		t.Errorf("request sending the frame: kept %v, error %v", kept, err)
+	}
+	got, kept, err := s.frameViewport(ctx, cached)
+	if err != nil || !kept {
+		t.Errorf("request of a kept frame: kept %v, error %v", kept, err)
+	}
+	if got.ReferenceOrbit == nil || len(got.ReferenceOrbit.Real) != len(viewport.ReferenceOrbit.Real) || got.Palette == nil || got.Palette.Name != "test" {
+		t.Errorf("request of a kept frame without the palette and the reference orbit of the frame")
+	}
+	cancel()
+
+	// The frame is kept for the next requests after its last one
+	ctx, cancel = s.frameContext(master, 1)
+	if _, kept, err := s.frameViewport(ctx, cached); err != nil || !kept {
+		t.Errorf("request of a frame kept between requests: kept %v, error %v", kept, err)
+	}
+	cancel()
+
+	// A newer frame replaces it
+	ctx, cancel = s.frameContext(master, 2)
+	defer cancel()
+	cached.Generation = 2
+	if _, _, err := s.frameViewport(ctx, cached); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("request of a newer frame not kept: error %v, want FailedPrecondition", err)
+	}
+}
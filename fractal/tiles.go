@@ -0,0 +1,102 @@
+package fractal
+
+import "sync"
+
+// TileSize is the side in pixels of the tiles the cluster splits the frames
+// into. Small tiles spread the expensive pixels of a frame between all the
+// nodes, big tiles save requests to the slave nodes.
+const TileSize = 64
+
+// LocalTileSize is the side in pixels of the tiles the local threads split
+// a region into.
+const LocalTileSize = 16
+
+// SlaveTilesPerRequest is the number of tiles requested at once to a slave
+// node, which streams them back one by one as it calculates them.
+const SlaveTilesPerRequest = 4
+
+// SlaveRequestsInFlight is the number of requests of tiles sent to every
+// slave node at once, so the slave node starts the next tiles while the last
+// ones are on their way.
+const SlaveRequestsInFlight = 2
+
+// tileQueue hands out the tiles of a region to the threads and slave nodes
+// that calculate them. Every one takes a new tile as soon as it finishes the
+// last one, so the fastest take more tiles and the work balances within the
+// frame wherever the expensive pixels are.
+type tileQueue struct {
+	mutex     sync.Mutex
+	cond      *sync.Cond
+	tiles     []Region // Tiles waiting to be taken
+	pending   int      // Tiles not calculated yet, taken or waiting
+	cancelled bool     // No more tiles are handed out
+}
+
+func newTileQueue(tiles []Region) *tileQueue {
+	q := &tileQueue{tiles: tiles, pending: len(tiles)}
+	q.cond = sync.NewCond(&q.mutex)
+	return q
+}
+
+// take returns the next tile to calculate, which must be released with done
+// or retry. While no tile is waiting it waits for the tiles taken by others,
+// which may be put back; it returns false once every tile is calculated or
+// the queue is cancelled.
+func (q *tileQueue) take() (Region, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for len(q.tiles) == 0 && q.pending > 0 && !q.cancelled {
+		q.cond.Wait()
+	}
+	return q.pop()
+}
+
+// takeWaiting returns the next tile waiting to be taken, without waiting for
+// the tiles taken by others. It returns false when no tile is waiting.
+func (q *tileQueue) takeWaiting() (Region, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.pop()
+}
+
+func (q *tileQueue) pop() (Region, bool) {
+	if len(q.tiles) == 0 || q.cancelled {
+		return Region{}, false
+	}
+	tile := q.tiles[0]
+	q.tiles = q.tiles[1:]
+	return tile, true
+}
+
+// done releases a tile calculated.
+func (q *tileQueue) done() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.pending--
+	if q.pending == 0 {
+		q.cond.Broadcast()
+	}
+}
+
+// retry puts back a tile that failed, to be taken by someone else.
+func (q *tileQueue) retry(tile Region) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.tiles = append(q.tiles, tile)
+	q.cond.Signal()
+}
+
+// cancel stops handing out tiles, the ones taken are left unfinished.
+func (q *tileQueue) cancel() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.cancelled = true
+	q.cond.Broadcast()
+}
+
+// complete reports whether every tile was calculated.
+func (q *tileQueue) complete() bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.pending == 0
+}
@@ -0,0 +1,79 @@
+package fractal
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestTiles(t *testing.T) {
+	region := Region{XStart: 10, YStart: 5, XEnd: 109, YEnd: 44}
+	tiles := region.Tiles(32)
+	if len(tiles) != 4*2 {
+		t.Fatalf("%d tiles, want 8", len(tiles))
+	}
+
+	covered := make(map[[2]int32]int)
+	for _, tile := range tiles {
+		if tile.Width() > 32 || tile.Height() > 32 {
+			t.Errorf("tile %+v bigger than 32x32", tile)
+		}
+		for y := tile.YStart; y <= tile.YEnd; y++ {
+			for x := tile.XStart; x <= tile.XEnd; x++ {
+				covered[[2]int32{x, y}]++
+			}
+		}
+	}
+	for y := region.YStart; y <= region.YEnd; y++ {
+		for x := region.XStart; x <= region.XEnd; x++ {
+			if covered[[2]int32{x, y}] != 1 {
+				t.Fatalf("pixel %d,%d in %d tiles", x, y, covered[[2]int32{x, y}])
+			}
+		}
+	}
+	if len(covered) != int(region.Width()*region.Height()) {
+		t.Errorf("tiles cover %d pixels, want %d", len(covered), region.Width()*region.Height())
+	}
+}
+
+func TestTileQueue(t *testing.T) {
+	tiles := Region{XEnd: 99, YEnd: 99}.Tiles(10)
+	queue := newTileQueue(tiles)
+
+	var mutex sync.Mutex
+	calculated := make(map[Region]int)
+	var workers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		workers.Add(1)
+		go func(failing bool) {
+			defer workers.Done()
+			for {
+				tile, ok := queue.take()
+				if !ok {
+					return
+				}
+				if failing {
+					// Like a slave node that fails, the others take its tile
+					queue.retry(tile)
+					return
+				}
+				mutex.Lock()
+				calculated[tile]++
+				mutex.Unlock()
+				queue.done()
+			}
+		}(i == 0)
+	}
+	workers.Wait()
+
+	if len(calculated) != len(tiles) {
+		t.Errorf("%d tiles calculated, want %d", len(calculated), len(tiles))
+	}
+	for tile, n := range calculated {
+		if n != 1 {
+			t.Errorf("tile %+v calculated %d times", tile, n)
+		}
+	}
+	if _, ok := queue.take(); ok {
+		t.Errorf("tile taken after every tile was calculated")
+	}
+}
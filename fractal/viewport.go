@@ -0,0 +1,249 @@
+package fractal
+
+import (
+	"math"
+	"math/big"
+)
+
+// DefaultBailoutRadius is the escape radius used when a viewport doesn't
+// define one. Any point whose orbit leaves the circle of radius 2 diverges.
+const DefaultBailoutRadius = 2
+
+// MaxFloat64Magnification is the deepest magnification factor rendered with
+// float64 arithmetic. Beyond it the distance between pixels gets close to the
+// float64 resolution and the iteration switches to arbitrary precision.
+const MaxFloat64Magnification = 1e13
+
+// MaxPerturbationMagnification is the deepest magnification factor rendered
+// with perturbation. Beyond it the float64 deltas of the pixels from the
+// reference orbit underflow, so every pixel is iterated with arbitrary
+// precision instead.
+const MaxPerturbationMagnification = 1e300
+
+// Viewport defines the area of the complex plane rendered in a frame and the
+// parameters used to calculate it.
+//
+// The magnification factor and the pan are arbitrary precision values so the
+// viewport can zoom deeper than float64 allows. They are never modified in
+// place, a new value is assigned instead, so viewports can be copied freely.
+type Viewport struct {
+	Width               int32
+	Height              int32
+	MagnificationFactor *big.Float
+	MaxIterations       float64
+	PanX                *big.Float
+	PanY                *big.Float
+	Coloring            ColoringMode
+	BailoutRadius       float64 // Escape radius of the orbits, DefaultBailoutRadius when 0
+	DeepZoom            DeepZoomMode
+	Formula             Formula         // Iterated formula, Mandelbrot when nil
+	Palette             *Palette        // Colors of the points outside the set, the hue bar when nil
+	Supersampling       int32           // Each pixel is the average of Supersampling x Supersampling samples, one sample when 0
+	ReferenceOrbit      *ReferenceOrbit // Orbit of the center used by the perturbation, calculated by the renderer when nil
+}
+
+// NewFloat returns an arbitrary precision value initialized to x.
+func NewFloat(x float64) *big.Float {
+	return new(big.Float).SetPrec(64).SetFloat64(x)
+}
+
+// ParseFloat parses an arbitrary precision value, keeping all the digits of s.
+func ParseFloat(s string) (*big.Float, error) {
+	// ~3.33 bits per decimal digit plus a float64 mantissa of margin
+	precision := uint(len(s))*10/3 + 64
+	x, _, err := big.ParseFloat(s, 10, precision, big.ToNearestEven)
+	return x, err
+}
+
+// Precision returns the mantissa bits needed to tell apart the pixels of the
+// viewport.
+func (v Viewport) Precision() uint {
+	precision := uint(64)
+	if exp := v.MagnificationFactor.MantExp(nil); exp > 0 {
+		precision += uint(exp)
+	}
+	return precision
+}
+
+// Log2Magnification returns the base 2 logarithm of the magnification
+// factor, the number of times the initial scale was doubled. It has no limit,
+// unlike the float64 value of the magnification factor.
+func (v Viewport) Log2Magnification() float64 {
+	mantissa := new(big.Float)
+	exp := v.MagnificationFactor.MantExp(mantissa)
+	m, _ := mantissa.Float64()
+	return math.Log2(m) + float64(exp)
+}
+
+// NeedsArbitraryPrecision reports whether the viewport is zoomed too deep to
+// be calculated with float64 arithmetic.
+func (v Viewport) NeedsArbitraryPrecision() bool {
+	return v.MagnificationFactor.Cmp(big.NewFloat(MaxFloat64Magnification)) > 0
+}
+
+// UsesPerturbation reports whether the viewport is calculated with
+// perturbation from a reference orbit.
+func (v Viewport) UsesPerturbation() bool {
+	return v.NeedsArbitraryPrecision() && v.DeepZoom == PerturbationDeepZoom && v.IsMandelbrot() &&
+		v.MagnificationFactor.Cmp(big.NewFloat(MaxPerturbationMagnification)) <= 0
+}
+
+// IteratedFormula returns the formula iterated in the viewport.
+func (v Viewport) IteratedFormula() Formula {
+	if v.Formula == nil {
+		return Mandelbrot{}
+	}
+	return v.Formula
+}
+
+// IsMandelbrot reports whether the viewport iterates the Mandelbrot formula.
+// The deep zoom modes are only available for it, the rest of the formulas are
+// always calculated with float64.
+func (v Viewport) IsMandelbrot() bool {
+	_, ok := v.IteratedFormula().(Mandelbrot)
+	return ok
+}
+
+// Coordinates converts a pixel position into its point in the complex plane.
+func (v Viewport) Coordinates(x int32, y int32) (float64, float64) {
+	cx, cy := v.PreciseCoordinates(x, y)
+	realComponent, _ := cx.Float64()
+	imaginaryComponent, _ := cy.Float64()
+	return realComponent, imaginaryComponent
+}
+
+// PreciseCoordinates converts a pixel position into its point in the complex
+// plane keeping the precision of the viewport.
+func (v Viewport) PreciseCoordinates(x int32, y int32) (*big.Float, *big.Float) {
+	return v.preciseCoordinates(float64(x), float64(y))
+}
+
+// preciseCoordinates is the version of PreciseCoordinates for positions
+// within pixels.
+func (v Viewport) preciseCoordinates(x float64, y float64) (*big.Float, *big.Float) {
+	precision := v.Precision()
+	cx := new(big.Float).SetPrec(precision).SetFloat64(x)
+	cx.Quo(cx, v.MagnificationFactor).Sub(cx, v.PanX)
+	cy := new(big.Float).SetPrec(precision).SetFloat64(y)
+	cy.Quo(cy, v.MagnificationFactor).Sub(cy, v.PanY)
+	return cx, cy
+}
+
+// MaxSupersampling is the max number of samples per side of a pixel.
+const MaxSupersampling = 8
+
+// Samples returns the number of samples calculated per pixel.
+func (v Viewport) Samples() int32 {
+	if v.Supersampling <= 1 {
+		return 1
+	}
+	return v.Supersampling * v.Supersampling
+}
+
+// sampleOffset returns the position of a sample within its pixel, relative to
+// the position of the pixel. The samples form a grid centered on it.
+func (v Viewport) sampleOffset(sample int32) (float64, float64) {
+	if v.Supersampling <= 1 {
+		return 0, 0
+	}
+	n := float64(v.Supersampling)
+	return (float64(sample%v.Supersampling)+0.5)/n - 0.5, (float64(sample/v.Supersampling)+0.5)/n - 0.5
+}
+
+// Bounds returns the region covering the whole frame.
+func (v Viewport) Bounds() Region {
+	return Region{XStart: 0, YStart: 0, XEnd: v.Width - 1, YEnd: v.Height - 1}
+}
+
+// Center returns the point of the complex plane at the center of the frame.
+func (v Viewport) Center() (*big.Float, *big.Float) {
+	precision := v.Precision()
+	halfWidth := new(big.Float).SetPrec(precision).SetFloat64(float64(v.Width) / 2)
+	halfHeight := new(big.Float).SetPrec(precision).SetFloat64(float64(v.Height) / 2)
+	x := halfWidth.Quo(halfWidth, v.MagnificationFactor).Sub(halfWidth, v.PanX)
+	y := halfHeight.Quo(halfHeight, v.MagnificationFactor).Sub(halfHeight, v.PanY)
+	return x, y
+}
+
+// SetCenter pans the viewport so the point (x, y) is at the center of the
+// frame for the current magnification factor.
+func (v *Viewport) SetCenter(x *big.Float, y *big.Float) {
+	v.pin(float64(v.Width)/2, float64(v.Height)/2, x, y)
+}
+
+// pin pans the viewport so the point (cx, cy) of the complex plane is under
+// the position (x, y) of the frame.
+func (v *Viewport) pin(x float64, y float64, cx *big.Float, cy *big.Float) {
+	precision := maxPrecision(v.Precision(), cx, cy)
+	panX := new(big.Float).SetPrec(precision).SetFloat64(x)
+	panY := new(big.Float).SetPrec(precision).SetFloat64(y)
+	v.PanX = panX.Quo(panX, v.MagnificationFactor).Sub(panX, cx)
+	v.PanY = panY.Quo(panY, v.MagnificationFactor).Sub(panY, cy)
+}
+
+// Move pans the viewport by (dx, dy) units of the complex plane.
+func (v *Viewport) Move(dx float64, dy float64) {
+	precision := maxPrecision(v.Precision(), v.PanX, v.PanY)
+	v.PanX = new(big.Float).SetPrec(precision).Add(v.PanX, big.NewFloat(dx))
+	v.PanY = new(big.Float).SetPrec(precision).Add(v.PanY, big.NewFloat(dy))
+}
+
+// Drag pans the viewport so the frame follows a drag of (dx, dy) pixels.
+func (v *Viewport) Drag(dx float64, dy float64) {
+	cx, cy := v.preciseCoordinates(0, 0)
+	v.pin(dx, dy, cx, cy)
+}
+
+// ZoomAt multiplies the magnification factor by factor keeping the point
+// under the position (x, y) of the frame in place.
+func (v *Viewport) ZoomAt(x float64, y float64, factor float64) {
+	cx, cy := v.preciseCoordinates(x, y)
+	v.MagnificationFactor = new(big.Float).Mul(v.MagnificationFactor, big.NewFloat(factor))
+	v.pin(x, y, cx, cy)
+}
+
+// ZoomToRectangle frames the rectangle between the positions (x0, y0) and
+// (x1, y1), centering it and keeping the aspect ratio of the frame.
+func (v *Viewport) ZoomToRectangle(x0 float64, y0 float64, x1 float64, y1 float64) {
+	width, height := math.Abs(x1-x0), math.Abs(y1-y0)
+	if width == 0 || height == 0 {
+		return
+	}
+	cx, cy := v.preciseCoordinates((x0+x1)/2, (y0+y1)/2)
+	factor := math.Min(float64(v.Width)/width, float64(v.Height)/height)
+	v.MagnificationFactor = new(big.Float).Mul(v.MagnificationFactor, big.NewFloat(factor))
+	v.SetCenter(cx, cy)
+}
+
+// Bailout returns the escape radius of the orbits of the viewport.
+func (v Viewport) Bailout() float64 {
+	if v.BailoutRadius <= 0 {
+		return DefaultBailoutRadius
+	}
+	return v.BailoutRadius
+}
+
+// maxPrecision returns the largest of precision and the precisions of values.
+func maxPrecision(precision uint, values ...*big.Float) uint {
+	for _, value := range values {
+		if value.Prec() > precision {
+			precision = value.Prec()
+		}
+	}
+	return precision
+}
+
+// JuliaViewport returns a viewport of width x height pixels framing the whole
+// Julia set of the point under the pixel (x, y) of v.
+func (v Viewport) JuliaViewport(x int32, y int32, width int32, height int32) Viewport {
+	cr, ci := v.Coordinates(x, y)
+	julia := v
+	julia.Width = width
+	julia.Height = height
+	julia.Formula = Julia{CReal: cr, CImaginary: ci}
+	julia.ReferenceOrbit = nil
+	// Julia sets fit in the circle of radius 2, most of them within [-1.6, 1.6]
+	julia.MagnificationFactor = NewFloat(float64(height) / 3.2)
+	julia.SetCenter(NewFloat(0), NewFloat(0))
+	return julia
+}
@@ -0,0 +1,65 @@
+package fractal
+
+import (
+	"math"
+	"testing"
+)
+
+func TestZoomAtKeepsPointInPlace(t *testing.T) {
+	viewport := Viewport{Width: 1280, Height: 720, MagnificationFactor: NewFloat(400), PanX: NewFloat(1.624203), PanY: NewFloat(0.620820)}
+	x, y := viewport.Coordinates(300, 200)
+	for _, factor := range []float64{1.25, 0.8, 1e20} {
+		viewport.ZoomAt(300, 200, factor)
+		if zx, zy := viewport.Coordinates(300, 200); math.Abs(zx-x) > 1e-12 || math.Abs(zy-y) > 1e-12 {
+			t.Errorf("ZoomAt(%v) moved the point (%v, %v) to (%v, %v)", factor, x, y, zx, zy)
+		}
+	}
+	if viewport.Precision() <= 64 {
+		t.Errorf("precision %d bits after zooming to %v, want more than 64", viewport.Precision(), viewport.MagnificationFactor)
+	}
+}
+
+func TestDrag(t *testing.T) {
+	viewport := Viewport{Width: 1280, Height: 720, MagnificationFactor: NewFloat(400), PanX: NewFloat(1.624203), PanY: NewFloat(0.620820)}
+	x, y := viewport.Coordinates(300, 200)
+	viewport.Drag(50, -20)
+	if dx, dy := viewport.Coordinates(350, 180); math.Abs(dx-x) > 1e-12 || math.Abs(dy-y) > 1e-12 {
+		t.Errorf("Drag(50, -20) moved the point (%v, %v) to (%v, %v)", x, y, dx, dy)
+	}
+}
+
+func TestZoomToRectangle(t *testing.T) {
+	viewport := Viewport{Width: 1280, Height: 720, MagnificationFactor: NewFloat(400), PanX: NewFloat(1.624203), PanY: NewFloat(0.620820)}
+	x, y := viewport.Coordinates(400, 300)
+	viewport.ZoomToRectangle(480, 360, 320, 240) // 160x120, the height limits the zoom
+	if magnification, _ := viewport.MagnificationFactor.Float64(); math.Abs(magnification-2400) > 1e-9 {
+		t.Errorf("magnification %v, want 2400", magnification)
+	}
+	if cx, cy := viewport.Coordinates(640, 360); math.Abs(cx-x) > 1e-12 || math.Abs(cy-y) > 1e-12 {
+		t.Errorf("center (%v, %v), want the center of the rectangle (%v, %v)", cx, cy, x, y)
+	}
+}
+
+func TestLog2Magnification(t *testing.T) {
+	magnifications := []struct {
+		value string
+		log2  float64
+	}{
+		{"1", 0},
+		{"0.25", -2},
+		{"400", math.Log2(400)},
+		{"1e13", 13 * math.Log2(10)},
+		{"1e400", 400 * math.Log2(10)}, // beyond float64
+	}
+
+	for _, m := range magnifications {
+		x, err := ParseFloat(m.value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		viewport := Viewport{MagnificationFactor: x}
+		if log2 := viewport.Log2Magnification(); math.Abs(log2-m.log2) > 1e-9 {
+			t.Errorf("Log2Magnification() = %v for %s, want %v", log2, m.value, m.log2)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"image"
+	"mandelbrot-fractal/fractal"
+)
+
+// Views kept in the navigation history
+const HISTORY_SIZE int = 100
+
+// Views next to the one shown that keep their frame, the rest are rendered
+// again when going back to them
+const HISTORY_FRAMES int = 8
+
+// HistoryEntry is a view of the navigation history and its frame.
+type HistoryEntry struct {
+	Viewport   fractal.Viewport
+	Image      *image.RGBA              // Frame of the view, nil when it must be rendered again
+	Iterations *fractal.IterationBuffer // Iteration data of the frame, to recolor it
+}
+
+// History is the stack of views visited, browsed with the Z (back) and X
+// (forward) keys.
+type History struct {
+	Entries []HistoryEntry
+	Current int // Entry of the view shown, len(Entries) when it is a new view
+}
+
+// Record adds the view shown before moving to a new one. The views ahead of
+// it, left by going back, are discarded.
+func (h *History) Record(shown HistoryEntry) {
+	h.Entries = append(h.Entries[:h.Current], shown)
+	if len(h.Entries) > HISTORY_SIZE {
+		h.Entries = h.Entries[len(h.Entries)-HISTORY_SIZE:]
+	}
+	h.Current = len(h.Entries)
+	h.dropFarFrames()
+}
+
+// Back returns the view before the one shown, which is kept to go forward
+// again.
+func (h *History) Back(shown HistoryEntry) (HistoryEntry, bool) {
+	if h.Current == 0 {
+		return HistoryEntry{}, false
+	}
+
+	if h.Current == len(h.Entries) {
+		h.Entries = append(h.Entries, shown)
+	} else {
+		h.Entries[h.Current] = shown
+	}
+	h.Current--
+	h.dropFarFrames()
+	return h.Entries[h.Current], true
+}
+
+// Forward returns the view after the one shown, left by going back.
+func (h *History) Forward(shown HistoryEntry) (HistoryEntry, bool) {
+	if h.Current >= len(h.Entries)-1 {
+		return HistoryEntry{}, false
+	}
+
+	h.Entries[h.Current] = shown
+	h.Current++
+	h.dropFarFrames()
+	return h.Entries[h.Current], true
+}
+
+// dropFarFrames releases the frames of the views far from the one shown.
+func (h *History) dropFarFrames() {
+	for i := range h.Entries {
+		if i < h.Current-HISTORY_FRAMES || i > h.Current+HISTORY_FRAMES {
+			h.Entries[i].Image = nil
+			h.Entries[i].Iterations = nil
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"image"
+	"mandelbrot-fractal/fractal"
+	"testing"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// historyStep records the view shown, or goes back or forward from it to
+// the view want, 0 when there is none.
+type historyStep struct {
+	op   string
+	view int
+	want int
+}
+
+// historyView is the entry of the view n, told apart by its max iterations.
+func historyView(n int) HistoryEntry {
+	return HistoryEntry{Viewport: fractal.Viewport{MaxIterations: float64(n)}, Image: image.NewRGBA(image.Rect(0, 0, 1, 1))}
+}
+
+// recordViews records the views from 1 to n.
+func recordViews(n int) []historyStep {
+	steps := make([]historyStep, n)
+	for i := range steps {
+		steps[i] = historyStep{op: "record", view: i + 1}
+	}
+	return steps
+}
+
+func TestHistory(t *testing.T) {
+	tests := []struct {
+		name        string
+		steps       []historyStep
+		wantEntries int
+		wantFirst   int // View of the oldest entry
+		wantFrames  int // Entries that keep their frame
+	}{
+		{
+			name:  "back at the start",
+			steps: []historyStep{{"back", 1, 0}},
+		},
+		{
+			name:        "forward at the end",
+			steps:       []historyStep{{"record", 1, 0}, {"forward", 2, 0}},
+			wantEntries: 1, wantFirst: 1, wantFrames: 1,
+		},
+		{
+			name: "back and forward to both ends",
+			steps: []historyStep{
+				{"record", 1, 0}, {"record", 2, 0},
+				{"back", 3, 2}, {"back", 2, 1}, {"back", 1, 0},
+				{"forward", 1, 2}, {"forward", 2, 3}, {"forward", 3, 0},
+			},
+			wantEntries: 3, wantFirst: 1, wantFrames: 3,
+		},
+		{
+			name: "record after back drops the views ahead",
+			steps: []historyStep{
+				{"record", 1, 0}, {"record", 2, 0},
+				{"back", 3, 2}, {"record", 2, 0},
+				{"forward", 4, 0}, {"back", 4, 2}, {"forward", 2, 4},
+			},
+			wantEntries: 3, wantFirst: 1, wantFrames: 3,
+		},
+		{
+			name:        "oldest views evicted",
+			steps:       recordViews(HISTORY_SIZE + 10),
+			wantEntries: HISTORY_SIZE, wantFirst: 11, wantFrames: HISTORY_FRAMES,
+		},
+	}
+
+	for _, test := range tests {
+		var history History
+		for _, step := range test.steps {
+			if step.op == "record" {
+				history.Record(historyView(step.view))
+				continue
+			}
+
+			browse := history.Back
+			if step.op == "forward" {
+				browse = history.Forward
+			}
+			entry, ok := browse(historyView(step.view))
+			if got := int(entry.Viewport.MaxIterations); ok != (step.want != 0) || got != step.want {
+				t.Errorf("%s: %s from view %d went to view %d (%v), want %d", test.name, step.op, step.view, got, ok, step.want)
+			}
+		}
+
+		if len(history.Entries) != test.wantEntries {
+			t.Errorf("%s: %d entries, want %d", test.name, len(history.Entries), test.wantEntries)
+			continue
+		}
+		if len(history.Entries) > 0 && int(history.Entries[0].Viewport.MaxIterations) != test.wantFirst {
+			t.Errorf("%s: oldest entry is view %v, want %d", test.name, history.Entries[0].Viewport.MaxIterations, test.wantFirst)
+		}
+		frames := 0
+		for _, entry := range history.Entries {
+			if entry.Image != nil {
+				frames++
+			}
+		}
+		if frames != test.wantFrames {
+			t.Errorf("%s: %d entries keep their frame, want %d", test.name, frames, test.wantFrames)
+		}
+	}
+}
+
+func TestShowKeptFrameAfterCancelledOne(t *testing.T) {
+	// The frame of the view shown was cancelled by going back
+	m := &Mandelbrot{
+		Viewport:   fractal.Viewport{MaxIterations: 2},
+		Pixels:     make([]rl.Color, 1),
+		Image:      image.NewRGBA(image.Rect(0, 0, 1, 1)),
+		Cluster:    &fractal.Cluster{},
+		NeedUpdate: true,
+		Stale:      true,
+	}
+	entry := historyView(1)
+	entry.Image.Pix[0] = 255
+	m.Show(entry)
+
+	if m.Stale || m.NeedUpdate {
+		t.Errorf("kept frame rendered again: stale %v, need update %v", m.Stale, m.NeedUpdate)
+	}
+	if m.Image != entry.Image || m.Pixels[0].R != 255 {
+		t.Errorf("kept frame not shown")
+	}
+	if m.Viewport.MaxIterations != 1 {
+		t.Errorf("view with %v max iterations shown, want 1", m.Viewport.MaxIterations)
+	}
+}
@@ -1,15 +1,21 @@
 /*
 
 - RUN ON A SINGLE COMPUTER:
-go run main.go
+go run .
 
 - RUN USING DISTRIBUTED COMPUTING:
 
 Run as master:
-go run main.go --role=master --slaves=127.0.0.1
+go run . --role=master --slaves=127.0.0.1
 
 Run as slave:
-go run main.go --role=slave
+go run . --role=slave
+
+Run as slave joining the cluster of a running master:
+go run . --role=slave --master=127.0.0.1:50050 --port=50052
+
+- RENDER A PNG FILE WITHOUT OPENING A WINDOW:
+go run . render --center=-0.5,0 --zoom=250 --output=mandelbrot.png
 
 */
 
@@ -21,66 +27,102 @@ import (
 	"fmt"
 	"github.com/gen2brain/raylib-go/raygui"
 	"github.com/gen2brain/raylib-go/raylib"
-	"github.com/lucasb-eyer/go-colorful"
-	"google.golang.org/grpc"
+	"image"
 	"log"
-	"mandelbrot-fractal/proto"
+	"mandelbrot-fractal/fractal"
 	"math"
-	"net"
+	"os"
+	"os/signal"
 	"runtime"
 	"strings"
-	"sync"
-	"time"
+	"syscall"
 )
 
 const MAX_THREADS int32 = 16
 const SCREEN_WIDTH int32 = 1280
 const SCREEN_HEIGHT int32 = 720
+const SLAVE_PORT int32 = 50051
+const REGISTRATION_PORT int32 = 50050
+const PALETTE_CYCLE_SPEED float64 = 0.2 // gradients per second shifted while cycling the palette
+const INITIAL_MAGNIFICATION float64 = 400
+const INITIAL_ITERATIONS float64 = 80
+const ITERATIONS_PER_OCTAVE float64 = 20 // max iterations added every time the magnification doubles
+const KEY_ZOOM_FACTOR float64 = 1.02     // times the magnification is multiplied every frame the A key is down
+const KEY_PAN_SPEED float64 = 8          // pixels moved every frame an arrow key is down
 
 type Mandelbrot struct {
-	ScreenWidth              int32
-	ScreenHeight             int32
-	Pixels                   []rl.Color
-	MagnificationFactor      float64
-	MaxIterations            float64
-	PanX                     float64
-	PanY                     float64
-	ThreadWaitGroup          sync.WaitGroup
-	DistributedWaitGroup     sync.WaitGroup
-	NeedUpdate               bool
-	MaxLocalThreads          int32
-	LocalThreadsProcessTimes []time.Duration
-	FrameProcessTime         time.Duration
-	ZoomLevel                float64
-	Canvas                   rl.RenderTexture2D
-	MovementOffset           [16]float64
-	IsMaster                 bool
-	SlavePort                int32
-	SlavesIPs                []string
-	SlavesClients            []proto.MandelbrotSlaveNodeClient // Used only in 'master' mode
-	SlavesCount              int32
-	NodesProcessTimes        []time.Duration   // Array of processing times of each slave node and the master node (last value in the array)
-	NodesRegions             []NodeRegion      // Array of regions data assigned to each node
-	NodesThreadsProcessTimes [][]time.Duration // Thread processing times of all slave nodes
-	BalancedWorkloads        []int32           // Array of values within range [0-100] defining the workload of each slave and the master (last value)
-	FragmentWidth            int32
-	FragmentHeight           int32
-	RGBBuffer                []byte
+	ScreenWidth  int32
+	ScreenHeight int32
+	Pixels       []rl.Color
+	Image        *image.RGBA
+	Viewport     fractal.Viewport
+	Cluster      *fractal.Cluster
+	NeedUpdate   bool
+	Canvas       rl.RenderTexture2D
+	JuliaPreview JuliaPreview
+	Drag         MouseDrag
+	Updated      bool   // the frame was recalculated in the last Update
+	CyclePalette bool   // shift the palette every frame
+	Bookmarks    string // location file where the B key saves the view
+	NextBookmark int    // bookmark shown by the L key
+
+	// Navigation history browsed with the Z and X keys
+	History       History
+	Navigating    bool // the view was moved in this frame
+	WasNavigating bool // the view was moved in the previous frame
+
+	// Tiles of the frame being rendered, shown as they arrive
+	Tiles ArrivedTiles
+	Stale bool // the last frame was cancelled by a new view before finishing, it is rendered again
 }
 
-type NodeRegion struct {
-	XStart int32
-	XEnd   int32
-	YStart int32
-	YEnd   int32
-	Width  int32
-	Height int32
+// Formulas switched with the F key
+var formulas = []fractal.Formula{
+	fractal.Mandelbrot{},
+	fractal.Julia{CReal: -0.8, CImaginary: 0.156},
+	fractal.BurningShip{},
+	fractal.Tricorn{},
+	fractal.Multibrot{Power: 3},
+}
+
+// Coloring modes switched with the M key
+var coloringModes = []fractal.ColoringMode{
+	fractal.IterationColoring,
+	fractal.SmoothColoring,
+	fractal.HistogramColoring,
+	fractal.DistanceColoring,
+	fractal.BoundaryColoring,
 }
 
 var nodeRole = flag.String("role", "master", "cluster node role: `master` or `slave`")
-var slavesIPs = flag.String("slaves", "", "cluster node slaves IP's separated by comas")
+var slavesIPs = flag.String("slaves", "", "cluster node slaves IP's (or IP:port) separated by comas")
+var registrationPort = flag.Int("registration-port", int(REGISTRATION_PORT), "port where slave nodes register with the master node, 0 to disable")
+var masterAddress = flag.String("master", "", "slave node: register with the master node at `IP:port` to join its cluster")
+var slavePort = flag.Int("port", int(SLAVE_PORT), "slave node: port where the regions are served")
+var slaveAddress = flag.String("address", "", "slave node: address sent to the master node, the one it sees when empty")
+var coloring = flag.String("coloring", "iterations", "coloring mode: `iterations`, smooth, histogram, distance or boundary")
+var deepZoom = flag.String("deep-zoom", "perturbation", "deep zoom calculation: `perturbation` or `arbitrary` precision")
+var formula = flag.String("formula", "mandelbrot", "iterated formula: `mandelbrot`, julia:<c real>,<c imaginary>, burningship, tricorn or multibrot:<power>")
+var bailout = flag.Float64("bailout", fractal.DefaultBailoutRadius, "escape radius of the orbits")
+var palette = flag.String("palette", "hue", "built-in palette (hue, ultrafractal, fire, ocean or grayscale) or gradient file (.json or .map)")
+var paletteOffset = flag.Float64("palette-offset", 0, "shift of the palette within [0, 1)")
+var paletteScale = flag.Float64("palette-scale", 1, "times the palette repeats from 0 to the max iterations")
+var location = flag.String("location", "", "start at a location of a location file: `file.json[#name]`, the last one of the file when no name is given")
+var bookmarks = flag.String("bookmarks", "bookmarks.json", "location file where the views are bookmarked")
+var supersampling = flag.Int("supersampling", 1, fmt.Sprintf("anti-aliasing, every pixel is the average of NxN samples, N up to %d: `N` (12 bytes per sample are kept in memory to recolor the frames)", fractal.MaxSupersampling))
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "render" {
+		err := runRenderCommand(os.Args[2:])
+		if err == flag.ErrHelp {
+			return
+		}
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		return
+	}
+
 	flag.Parse()
 
 	// Ask the Golang runtime how many CPU cores are available
@@ -104,152 +146,203 @@ func main() {
 	// Set-up the Go runtime to use all the available CPU cores
 	runtime.GOMAXPROCS(totalCores)
 
-	if isMaster {
-		rl.InitWindow(SCREEN_WIDTH, SCREEN_HEIGHT, "Mandelbrot fractal")
-		rl.SetTargetFPS(30)
+	if !isMaster {
+		if len(*masterAddress) > 0 {
+			registerWithMasterNode(*masterAddress, *slaveAddress, int32(*slavePort), int32(totalCores))
+		}
+
+		fmt.Println("\nListening for Mandelbrot jobs at 0.0.0.0 on port", *slavePort)
+		if err := fractal.ProcessRequestsFromMasterNode(int32(*slavePort), MAX_THREADS); err != nil {
+			log.Fatalf("%v", err)
+		}
+		return
 	}
 
-	fractal := Mandelbrot{}
-	fractal.Init(isMaster, slaves)
+	coloringMode, err := fractal.ParseColoringMode(*coloring)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
 
-	if isMaster {
-		fmt.Println("\n- Use keys A and S for zoom-in and zoom-out.")
-		fmt.Println("- Use arrow keys to navigate.\n")
+	deepZoomMode, err := fractal.ParseDeepZoomMode(*deepZoom)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
 
-		for !rl.WindowShouldClose() {
-			fractal.Update()
-			fractal.Draw()
-			fractal.ProcessKeyboard()
+	iteratedFormula, err := fractal.ParseFormula(*formula)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	if *bailout < 2 {
+		log.Fatalf("invalid bailout radius %v, it must be at least 2", *bailout)
+	}
+
+	colorPalette, err := loadPalette(*palette, *paletteOffset, *paletteScale)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	if *supersampling < 1 || *supersampling > fractal.MaxSupersampling {
+		log.Fatalf("invalid supersampling %d, it must be between 1 and %d", *supersampling, fractal.MaxSupersampling)
+	}
+
+	var startLocation *fractal.Location
+	if len(*location) > 0 {
+		l, err := loadLocation(*location)
+		if err != nil {
+			log.Fatalf("%v", err)
 		}
 
-		rl.UnloadTexture(fractal.Canvas.Texture)
-		rl.CloseWindow()
-	} else {
-		fractal.ProcessRequestsFromMasterNode()
+		// The formula and palette flags take precedence over the location
+		explicit := explicitFlags(flag.CommandLine)
+		if explicit["formula"] {
+			l.Formula = iteratedFormula
+		}
+		if explicit["palette"] || explicit["palette-offset"] || explicit["palette-scale"] {
+			l.Palette = colorPalette
+		}
+		startLocation = &l
 	}
+
+	rl.InitWindow(SCREEN_WIDTH, SCREEN_HEIGHT, "Mandelbrot fractal")
+	rl.SetTargetFPS(30)
+
+	mandelbrot := Mandelbrot{}
+	mandelbrot.Init(slaves, fractal.Viewport{Coloring: coloringMode, BailoutRadius: *bailout, DeepZoom: deepZoomMode, Formula: iteratedFormula, Palette: colorPalette, Supersampling: int32(*supersampling)})
+	mandelbrot.Bookmarks = *bookmarks
+	if *registrationPort > 0 {
+		// Another master node may already listen on the port, this one works
+		// without the slave nodes registering
+		if err := mandelbrot.Cluster.ListenForSlaves(int32(*registrationPort)); err != nil {
+			log.Printf("Registration of slave nodes disabled: %v", err)
+		} else {
+			fmt.Println("- Slave nodes can register on port", *registrationPort)
+		}
+	}
+	if startLocation != nil {
+		mandelbrot.Viewport.GoTo(*startLocation)
+	}
+
+	fmt.Println("\n- Use keys A and S for zoom-in and zoom-out.")
+	fmt.Println("- Use arrow keys to navigate.")
+	fmt.Println("- Use the mouse wheel to zoom at the pointer, drag to move, click to center and drag with the right button to zoom into a rectangle.")
+	fmt.Println("- Use key F to switch the fractal formula.")
+	fmt.Println("- Use key J to show the Julia set under the mouse pointer, P to hide its preview.")
+	fmt.Println("- Use key N to switch the palette, [ and ] to shift it, - and = to scale it and C to cycle it.")
+	fmt.Println("- Use key M to switch the coloring mode.")
+	fmt.Println("- Use keys Z and X to go back and forward in the navigation history.")
+	fmt.Printf("- Use key B to bookmark the view in %s and L to go to the next bookmark.\n", *bookmarks)
+
+	for !rl.WindowShouldClose() {
+		// A frame cancelled by the input goes straight to process it, drawing
+		// would poll the input again and lose the keys pressed
+		if cancelled := mandelbrot.Update(); !cancelled {
+			mandelbrot.Draw()
+		}
+		mandelbrot.ProcessKeyboard()
+		mandelbrot.ProcessMouse()
+	}
+
+	rl.UnloadTexture(mandelbrot.Canvas.Texture)
+	rl.UnloadTexture(mandelbrot.JuliaPreview.Canvas.Texture)
+	rl.CloseWindow()
 }
 
 // Mandelbrot functions
 
-func (m *Mandelbrot) Init(isMaster bool, slavesIPs []string) {
+// Init sets up the window state and the cluster. The coloring settings of
+// the frames are taken from viewport, which is moved to the initial location.
+func (m *Mandelbrot) Init(slavesIPs []string, viewport fractal.Viewport) {
 	m.ScreenWidth = SCREEN_WIDTH
 	m.ScreenHeight = SCREEN_HEIGHT
-	m.ZoomLevel = 0.1
-	m.MagnificationFactor = 400
-	m.MaxIterations = 80
-	m.PanX = 1.624203
-	m.PanY = 0.620820
-	m.MovementOffset = [...]float64{
-		0.018666, 0.017666, 0.016666, 0.015000,
-		0.002950, 0.000400, 0.000025, 0.0000025,
-		0.00000025, 0.000000025, 0.0000000025, 0.0000000025,
-		0.00000000025, 0.000000000025, 0.0000000000025, 0.00000000000025}
+	m.Viewport = viewport
+	m.Viewport.Width = m.ScreenWidth
+	m.Viewport.Height = m.ScreenHeight
+	m.Viewport.MagnificationFactor = fractal.NewFloat(INITIAL_MAGNIFICATION)
+	m.Viewport.MaxIterations = INITIAL_ITERATIONS
+	m.Viewport.PanX = fractal.NewFloat(1.624203)
+	m.Viewport.PanY = fractal.NewFloat(0.620820)
 	m.NeedUpdate = true
-	m.MaxLocalThreads = MAX_THREADS
-	m.LocalThreadsProcessTimes = make([]time.Duration, m.MaxLocalThreads)
-	m.FragmentWidth = int32(math.Ceil(float64(m.ScreenWidth-1) / float64(m.MaxLocalThreads)))
-	m.FragmentHeight = m.ScreenHeight - 1
-	m.SlavePort = 50051
-	m.IsMaster = isMaster
-
-	if m.IsMaster {
-		m.Canvas = rl.LoadRenderTexture(m.ScreenWidth, m.ScreenHeight)
-		m.SlavesCount = int32(len(slavesIPs))
-		m.SlavesIPs = make([]string, m.SlavesCount)
-		m.SlavesClients = make([]proto.MandelbrotSlaveNodeClient, m.SlavesCount)
-		m.NodesProcessTimes = make([]time.Duration, m.SlavesCount+1)        // processing times for each each slave and the master (last value in array)
-		m.NodesThreadsProcessTimes = make([][]time.Duration, m.SlavesCount) // thread processing times of all nodes in the cluster (slaves and master)
-
-		// This array stores all slaves IPs
-		for i := int32(0); i < m.SlavesCount; i++ {
-			m.SlavesIPs[i] = slavesIPs[i]
-		}
+	m.Canvas = rl.LoadRenderTexture(m.ScreenWidth, m.ScreenHeight)
 
-		// This array stores all thread processing times of all slave nodes
-		for i := int32(0); i < m.SlavesCount; i++ {
-			m.NodesThreadsProcessTimes[i] = make([]time.Duration, m.MaxLocalThreads)
-		}
-
-		m.BalancedWorkloads = make([]int32, m.SlavesCount+1) // balanced workloads for each slave and the master (last value in array)
-		m.NodesRegions = make([]NodeRegion, m.SlavesCount+1) // balanced workloads for each slave and the master (last value in array)
-
-		// Set initial relative workload values for each slave node and the master node
-		portion_acc := int32(0)
-		for d := int32(0); d < m.SlavesCount; d++ {
-			workload_portion := 100 / float64(m.SlavesCount+1) //float64(m.SlavesCount + 1)
-			if d%2 == 0 {
-				m.BalancedWorkloads[d] = int32(math.Floor(workload_portion))
-			} else {
-				m.BalancedWorkloads[d] = int32(math.Ceil(workload_portion))
-			}
-			portion_acc += m.BalancedWorkloads[d]
-		}
-		m.BalancedWorkloads[m.SlavesCount] = int32(math.Abs(100 - float64(portion_acc))) // master worload
-
-		// Initialize the gRPC client for each slave node
-		for c := int32(0); c < m.SlavesCount; c++ {
-			address := fmt.Sprintf("%s:%d", slavesIPs[c], m.SlavePort)
-			fmt.Printf("- Connecting to slave node at %s... ", address)
-			conn, err := grpc.Dial(address, grpc.WithInsecure(), grpc.WithBlock())
-			if err != nil {
-				log.Fatalf(" [ ERROR ] Cannot connect: %v", err)
-			}
-			fmt.Print("[ OK ]\n")
-			m.SlavesClients[c] = proto.NewMandelbrotSlaveNodeClient(conn)
-		}
+	// Initialize the gRPC client for each slave node
+	fmt.Printf("- Connecting to slave nodes %v... ", slavesIPs)
+	cluster, err := fractal.NewCluster(MAX_THREADS, slavesIPs, SLAVE_PORT)
+	if err != nil {
+		log.Fatalf(" [ ERROR ] %v", err)
 	}
+	fmt.Print("[ OK ]\n")
+	m.Cluster = cluster
+	m.Cluster.OnTile = m.Tiles.Add
+	m.JuliaPreview.Init(m.Cluster.Renderer)
+	m.Drag.Button = -1
 
 	// Initialize the pixel matrix
+	m.Image = image.NewRGBA(m.Viewport.Bounds().Rect())
 	m.Pixels = make([]rl.Color, m.ScreenWidth*m.ScreenHeight)
 	for i := int32(0); i < int32(len(m.Pixels)); i++ {
 		m.Pixels[i] = rl.NewColor(0, 0, 0, 255)
 	}
 }
 
-func (m *Mandelbrot) Update() {
-	if !m.NeedUpdate {
-		return
+// Update renders the frame when the view changed. It returns true when the
+// frame was cancelled by the input, see RenderProgressively.
+func (m *Mandelbrot) Update() bool {
+	m.Updated = m.NeedUpdate
+	cancelled := false
+	if m.NeedUpdate {
+		cancelled = m.RenderProgressively()
 	}
 
-	start := time.Now()
+	m.JuliaPreview.Update(m.Viewport, m.Updated)
+	return cancelled
+}
 
-	if m.SlavesCount == 0 {
-		// SINGLE COMPUTER
-		for i := int32(0); i < m.MaxLocalThreads; i++ {
-			m.ThreadWaitGroup.Add(1)
-			go m.CalculateFragmentInThread(i, i*m.FragmentWidth, 0, i*m.FragmentWidth+m.FragmentWidth, m.FragmentHeight-1, 0, m.ScreenWidth-1)
-		}
-		m.ThreadWaitGroup.Wait()
+func (m *Mandelbrot) Draw() {
+	rl.BeginDrawing()
+	m.DrawFrame()
 
-	} else {
-		// DISTRIBUTED COMPUTING
-		regionIndex := int32(0)
+	raygui.SetStyleProperty(raygui.GlobalTextFontsize, 14.0)
+	raygui.SetStyleProperty(raygui.GlobalTextColor, 9999999)
 
-		// Upload workloads according to previous master and slaves processing times
-		m.UpdateAndBalanceWorkload()
+	label_height := 14
+	// Show master node tiles and threads processing times
+	localThreadsProcessTimes := m.Cluster.Renderer.LocalThreadsProcessTimes
+	raygui.Label(rl.NewRectangle(0, 8, 40, float32(label_height)), fmt.Sprintf("MASTER (%d tiles)\n", m.Cluster.NodesTiles[m.Cluster.SlavesCount]))
+	for thread_index := 0; thread_index < len(localThreadsProcessTimes); thread_index++ {
+		raygui.Label(rl.NewRectangle(0, float32(20+8+thread_index*(label_height+8)), 100, float32(label_height)), fmt.Sprintf("Thread %d: %s\n", thread_index, localThreadsProcessTimes[thread_index]))
+	}
 
-		// Calculate each region separatelly in a slave node identified by 'regionIndex'
-		for regionIndex = 0; regionIndex < m.SlavesCount; regionIndex++ {
-			node_region := m.NodesRegions[regionIndex]
-			m.DistributedWaitGroup.Add(1)
-			go m.CalculateRegionInSlaveNode(regionIndex, node_region.XStart, node_region.YStart, node_region.XEnd, node_region.YEnd)
+	// Show slave nodes health, tiles and threads processing times
+	nodesThreadsProcessTimes := m.Cluster.NodesThreadsProcessTimes
+	for region_index := 0; region_index < len(nodesThreadsProcessTimes); region_index++ {
+		raygui.Label(rl.NewRectangle(float32(region_index+1)*160, 8, 40, float32(label_height)), fmt.Sprintf("NODE %d (%s)\n", region_index, m.Cluster.SlaveAddress(int32(region_index))))
+		raygui.Label(rl.NewRectangle(float32(region_index+1)*160, float32(20+8), 100, float32(label_height)), fmt.Sprintf("(%s%s, %d tiles)\n", m.Cluster.SlavesStates[region_index], coresLabel(m.Cluster.SlavesCores[region_index]), m.Cluster.NodesTiles[region_index]))
+		for thread_index := 0; thread_index < len(nodesThreadsProcessTimes[region_index]); thread_index++ {
+			raygui.Label(rl.NewRectangle(float32(region_index+1)*160, float32(20+8+(thread_index+1)*(label_height+8)), 100, float32(label_height)), fmt.Sprintf("Thread %d: %s\n", thread_index, nodesThreadsProcessTimes[region_index][thread_index]))
 		}
+	}
 
-		// Calculate one region locally (master node)
-		master_start := time.Now()
-		node_region := m.NodesRegions[regionIndex]
-		m.CalculateRegionLocally(node_region.XStart, node_region.YStart, node_region.XEnd, node_region.YEnd)
-		m.NodesProcessTimes[regionIndex] = time.Since(master_start) // last item in NodesProcessTimes is used to save the process time of the master node
-
-		// Wait for all distributed calculations
-		m.DistributedWaitGroup.Wait()
+	// Show frame total processing time and rendering FPS
+	precision := "float64"
+	if m.Viewport.UsesPerturbation() {
+		precision = fmt.Sprintf("perturbation, %d bits reference", m.Viewport.Precision())
+	} else if m.Viewport.NeedsArbitraryPrecision() {
+		precision = fmt.Sprintf("%d bits", m.Viewport.Precision())
 	}
+	raygui.Label(rl.NewRectangle(0, float32(m.ScreenHeight-140), 100, float32(label_height)), fmt.Sprintf("(Coloring: %s)\n", m.Viewport.Coloring))
+	raygui.Label(rl.NewRectangle(0, float32(m.ScreenHeight-120), 100, float32(label_height)), fmt.Sprintf("(Palette: %s)\n", m.Viewport.Palette.Name))
+	raygui.Label(rl.NewRectangle(0, float32(m.ScreenHeight-100), 100, float32(label_height)), fmt.Sprintf("(Formula: %s)\n", m.Viewport.IteratedFormula()))
+	raygui.Label(rl.NewRectangle(0, float32(m.ScreenHeight-80), 100, float32(label_height)), fmt.Sprintf("(Precision: %s)\n", precision))
+	raygui.Label(rl.NewRectangle(0, float32(m.ScreenHeight-60), 100, float32(label_height)), fmt.Sprintf("(Iterations skipped: %d)\n", m.Cluster.SkippedIterations))
+	raygui.Label(rl.NewRectangle(0, float32(m.ScreenHeight-40), 100, float32(label_height)), fmt.Sprintf("(Frame time: %s)\n", m.Cluster.FrameProcessTime))
+	raygui.Label(rl.NewRectangle(0, float32(m.ScreenHeight-20), 100, float32(label_height)), fmt.Sprintf("(FPS: %f)\n", rl.GetFPS()))
 
-	m.FrameProcessTime = time.Since(start)
+	rl.EndDrawing()
 }
 
-func (m *Mandelbrot) Draw() {
-	rl.BeginDrawing()
+// DrawFrame draws the frame and the views over it.
+func (m *Mandelbrot) DrawFrame() {
 	rl.ClearBackground(rl.Black)
 
 	// Send updated texture from RAM to GPU
@@ -258,252 +351,365 @@ func (m *Mandelbrot) Draw() {
 	// Render texture in GPU to screen
 	rl.DrawTexture(m.Canvas.Texture, 0, 0, rl.RayWhite)
 
-	raygui.SetStyleProperty(raygui.GlobalTextFontsize, 14.0)
-	raygui.SetStyleProperty(raygui.GlobalTextColor, 9999999)
-
-	label_height := 14
-	// Show master node threads processing times
-	raygui.Label(rl.NewRectangle(0, 8, 40, float32(label_height)), fmt.Sprintf("MASTER\n"))
-	for thread_index := 0; thread_index < len(m.LocalThreadsProcessTimes); thread_index++ {
-		raygui.Label(rl.NewRectangle(0, float32(20+8+thread_index*(label_height+8)), 100, float32(label_height)), fmt.Sprintf("Thread %d: %s\n", thread_index, m.LocalThreadsProcessTimes[thread_index]))
-	}
+	// Rectangle to zoom into
+	m.Drag.Draw()
 
-	// Show slave nodes threads processing times
-	for region_index := 0; region_index < len(m.NodesThreadsProcessTimes); region_index++ {
-		raygui.Label(rl.NewRectangle(float32(region_index+1)*160, 8, 40, float32(label_height)), fmt.Sprintf("NODE %d (%s)\n", region_index, m.SlavesIPs[region_index]))
-		for thread_index := 0; thread_index < len(m.NodesThreadsProcessTimes[region_index]); thread_index++ {
-			raygui.Label(rl.NewRectangle(float32(region_index+1)*160, float32(20+8+thread_index*(label_height+8)), 100, float32(label_height)), fmt.Sprintf("Thread %d: %s\n", thread_index, m.LocalThreadsProcessTimes[thread_index]))
-		}
+	// Julia set of the point under the mouse pointer
+	if m.Viewport.IsMandelbrot() {
+		m.JuliaPreview.Draw(m.ScreenWidth, m.ScreenHeight)
 	}
-
-	// Show frame total processing time and rendering FPS
-	raygui.Label(rl.NewRectangle(0, float32(m.ScreenHeight-40), 100, float32(label_height)), fmt.Sprintf("(Frame time: %s)\n", m.FrameProcessTime))
-	raygui.Label(rl.NewRectangle(0, float32(m.ScreenHeight-20), 100, float32(label_height)), fmt.Sprintf("(FPS: %f)\n", rl.GetFPS()))
-
-	rl.EndDrawing()
 }
 
 func (m *Mandelbrot) ProcessKeyboard() {
-	m.NeedUpdate = false
+	m.NeedUpdate = m.Stale
+	m.WasNavigating, m.Navigating = m.Navigating, false
+	if m.CyclePalette {
+		m.Viewport.Palette = m.Viewport.Palette.WithOffset(m.Viewport.Palette.Offset + PALETTE_CYCLE_SPEED*float64(rl.GetFrameTime()))
+		m.Recolor()
+	}
+
 	if rl.IsKeyDown(rl.KeyLeft) {
-		m.PanX -= m.MovementOffset[int(m.ZoomLevel)]
+		m.BeginNavigation()
+		m.Viewport.Drag(-KEY_PAN_SPEED, 0)
 		m.NeedUpdate = true
 	}
 
 	if rl.IsKeyDown(rl.KeyRight) {
-		m.PanX += m.MovementOffset[int(m.ZoomLevel)]
+		m.BeginNavigation()
+		m.Viewport.Drag(KEY_PAN_SPEED, 0)
 		m.NeedUpdate = true
 	}
 
 	if rl.IsKeyDown(rl.KeyUp) {
-		m.PanY -= m.MovementOffset[int(m.ZoomLevel)]
+		m.BeginNavigation()
+		m.Viewport.Drag(0, -KEY_PAN_SPEED)
 		m.NeedUpdate = true
 	}
 
 	if rl.IsKeyDown(rl.KeyDown) {
-		m.PanY += m.MovementOffset[int(m.ZoomLevel)]
+		m.BeginNavigation()
+		m.Viewport.Drag(0, KEY_PAN_SPEED)
 		m.NeedUpdate = true
 	}
 
-	if rl.IsKeyDown(rl.KeyA) {
-		m.ZoomLevel += 0.01
-		m.MagnificationFactor = 400 + math.Exp2(m.ZoomLevel*3)
-		m.MaxIterations = 80 + 50*m.ZoomLevel
+	if rl.IsKeyPressed(rl.KeyF) {
+		m.BeginNavigation()
+		m.NextFormula()
 		m.NeedUpdate = true
 	}
 
-	if rl.IsKeyDown(rl.KeyS) {
-		m.ZoomLevel -= 0.01
-		m.MagnificationFactor = 400 + math.Exp2(m.ZoomLevel*3)
-		m.MaxIterations = 80 + 50*m.ZoomLevel
+	if rl.IsKeyPressed(rl.KeyJ) && m.Viewport.IsMandelbrot() && m.JuliaPreview.MouseX >= 0 {
+		m.BeginNavigation()
+		// Swap the main view to the Julia set of the preview
+		m.Viewport = m.Viewport.JuliaViewport(m.JuliaPreview.MouseX, m.JuliaPreview.MouseY, m.ScreenWidth, m.ScreenHeight)
+		m.UpdateMaxIterations()
 		m.NeedUpdate = true
 	}
-}
 
-func (m *Mandelbrot) UpdateAndBalanceWorkload() {
-	var minProcessTime, maxProcessTime time.Duration = 1 * time.Hour, 0
-	var minProcessTimeRegionIndex, maxProcessTimeRegionIndex int32 = 0, 0
+	if rl.IsKeyPressed(rl.KeyP) {
+		m.JuliaPreview.Visible = !m.JuliaPreview.Visible
+		m.JuliaPreview.MouseX, m.JuliaPreview.MouseY = -1, -1
+	}
+
+	if rl.IsKeyPressed(rl.KeyN) {
+		m.NextPalette()
+		m.Recolor()
+	}
 
-	// Search for the fastest and the slowest node
-	for i := int32(0); i <= m.SlavesCount; i++ {
-		if m.NodesProcessTimes[i] < minProcessTime {
-			minProcessTime = m.NodesProcessTimes[i]
-			minProcessTimeRegionIndex = i
+	if rl.IsKeyPressed(rl.KeyM) {
+		m.NextColoring()
+		m.Recolor()
+	}
+
+	if rl.IsKeyPressed(rl.KeyB) {
+		if err := m.Bookmark(); err != nil {
+			fmt.Printf("- Cannot bookmark the view: %v\n", err)
 		}
+	}
 
-		if m.NodesProcessTimes[i] > maxProcessTime {
-			maxProcessTime = m.NodesProcessTimes[i]
-			maxProcessTimeRegionIndex = i
+	if rl.IsKeyPressed(rl.KeyL) {
+		if err := m.GoToNextBookmark(); err != nil {
+			fmt.Printf("- Cannot go to the next bookmark: %v\n", err)
 		}
 	}
 
-	// Balance the fastest and the slowest node
-	if (m.BalancedWorkloads[minProcessTimeRegionIndex] < 100) && (m.BalancedWorkloads[maxProcessTimeRegionIndex] > 0) && (minProcessTimeRegionIndex != maxProcessTimeRegionIndex) {
-		m.BalancedWorkloads[minProcessTimeRegionIndex]++
-		m.BalancedWorkloads[maxProcessTimeRegionIndex]--
+	if rl.IsKeyPressed(rl.KeyZ) {
+		if entry, ok := m.History.Back(m.ShownView()); ok {
+			m.Show(entry)
+		}
 	}
 
-	// Update node regions according to the new workloads calculated
-	x := int32(0)
-	for i := int32(0); i <= m.SlavesCount; i++ {
-		workload := float64(m.BalancedWorkloads[i]) / 100
-		m.NodesRegions[i].XStart = x
-		m.NodesRegions[i].Width = int32(float64(m.ScreenWidth) * workload)
-		x += m.NodesRegions[i].Width - 1
-		m.NodesRegions[i].XEnd = x
-		x++
+	if rl.IsKeyPressed(rl.KeyX) {
+		if entry, ok := m.History.Forward(m.ShownView()); ok {
+			m.Show(entry)
+		}
+	}
 
-		m.NodesRegions[i].YStart = 0
-		m.NodesRegions[i].YEnd = m.ScreenHeight - 1
-		m.NodesRegions[i].Height = m.ScreenHeight
+	if rl.IsKeyPressed(rl.KeyC) {
+		m.CyclePalette = !m.CyclePalette
 	}
-}
 
-func (m *Mandelbrot) CalculateRegionInSlaveNode(region_index int32, x_start int32, y_start int32, x_end int32, y_end int32) {
-	defer m.DistributedWaitGroup.Done()
+	if rl.IsKeyDown(rl.KeyLeftBracket) {
+		m.Viewport.Palette = m.Viewport.Palette.WithOffset(m.Viewport.Palette.Offset - 0.005)
+		m.Recolor()
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
-	defer cancel()
+	if rl.IsKeyDown(rl.KeyRightBracket) {
+		m.Viewport.Palette = m.Viewport.Palette.WithOffset(m.Viewport.Palette.Offset + 0.005)
+		m.Recolor()
+	}
 
-	start := time.Now()
+	if rl.IsKeyPressed(rl.KeyMinus) {
+		m.Viewport.Palette = m.Viewport.Palette.WithScale(m.Viewport.Palette.Scale / 1.25)
+		m.Recolor()
+	}
 
-	regionWidth := x_end - x_start + 1
-	regionHeight := y_end - y_start + 1
+	if rl.IsKeyPressed(rl.KeyEqual) {
+		m.Viewport.Palette = m.Viewport.Palette.WithScale(m.Viewport.Palette.Scale * 1.25)
+		m.Recolor()
+	}
 
-	// Send the job to the slave node with the region to calculate
-	response, err := m.SlavesClients[region_index].CalculateRegion(ctx, &proto.CalculateRegionRequest{MagnificationFactor: m.MagnificationFactor, MaxIterations: m.MaxIterations, PanX: m.PanX, PanY: m.PanY, Index: region_index, Width: regionWidth, Height: regionHeight, XStart: x_start, YStart: y_start, XEnd: x_end, YEnd: y_end})
-	if err != nil {
-		log.Fatalf("An error occurred when fetching data from slave node (%d) error: (%v)", region_index, err)
+	if rl.IsKeyDown(rl.KeyA) {
+		m.BeginNavigation()
+		m.Viewport.ZoomAt(float64(m.ScreenWidth)/2, float64(m.ScreenHeight)/2, KEY_ZOOM_FACTOR)
+		m.UpdateMaxIterations()
+		m.NeedUpdate = true
 	}
 
-	// Save the time spent by slave node to receive, process and return the region calculated
-	m.NodesProcessTimes[region_index] = time.Since(start)
+	if rl.IsKeyDown(rl.KeyS) {
+		m.BeginNavigation()
+		m.Viewport.ZoomAt(float64(m.ScreenWidth)/2, float64(m.ScreenHeight)/2, 1/KEY_ZOOM_FACTOR)
+		m.UpdateMaxIterations()
+		m.NeedUpdate = true
+	}
+}
 
-	// RGB buffer with calculated region values(pixels) in RGB
-	rgbBuffer := response.GetRGBPixels()
-	slaveThreadsProcessTimesInt64 := response.GetThreadsProcessTimes()
+// UpdateMaxIterations derives the max iterations from the magnification
+// factor of the view: the deeper the zoom, the more iterations the points
+// close to the set need to escape.
+func (m *Mandelbrot) UpdateMaxIterations() {
+	octaves := m.Viewport.Log2Magnification() - math.Log2(INITIAL_MAGNIFICATION)
+	m.Viewport.MaxIterations = INITIAL_ITERATIONS + ITERATIONS_PER_OCTAVE*math.Max(octaves, 0)
+}
 
-	// Update local buffer with the region calculated in a slave node
-	var i int32 = 0
-	for x := x_start; (x <= x_end) && (x < m.ScreenWidth); x++ {
-		for y := y_start; y < y_end; y++ {
-			// Update region pixels with the calculated values by the slave node
-			m.Pixels[(m.ScreenWidth*y)+x] = rl.NewColor(rgbBuffer[i*3], rgbBuffer[i*3+1], rgbBuffer[i*3+2], 255) // RGBA
-			i++
-		}
+// BeginNavigation records the view shown in the navigation history before
+// moving it, once per navigation: the keys held and the mouse drags move the
+// view during several frames. The frame of the view is kept with it.
+func (m *Mandelbrot) BeginNavigation() {
+	m.Navigating = true
+	if m.WasNavigating {
+		return
 	}
+	m.WasNavigating = true
 
-	// Store slave node threads processing times (used only to show node stats)
-	for e := int32(0); e < m.MaxLocalThreads; e++ {
-		m.NodesThreadsProcessTimes[region_index][e] = time.Duration(slaveThreadsProcessTimesInt64[e]) * time.Nanosecond
-	}
+	m.History.Record(m.ShownView())
+	m.Image = image.NewRGBA(m.Image.Rect)
+	m.Cluster.Iterations = nil
 }
 
-func (m *Mandelbrot) CalculateRegionLocally(x_start int32, y_start int32, x_end int32, y_end int32) {
-	regionWidth := x_end - x_start
-	fragmentWidth := int32(math.Ceil(float64(regionWidth) / float64(m.MaxLocalThreads)))
-	fragmentHeight := y_end - y_start
+// ShownView returns the view shown and its frame as a history entry. The
+// frame of a stale view isn't kept, it is rendered again.
+func (m *Mandelbrot) ShownView() HistoryEntry {
+	if m.Stale {
+		return HistoryEntry{Viewport: m.Viewport}
+	}
+	return HistoryEntry{Viewport: m.Viewport, Image: m.Image, Iterations: m.Cluster.Iterations}
+}
 
-	for i := int32(0); i < m.MaxLocalThreads; i++ {
-		m.ThreadWaitGroup.Add(1)
-		go m.CalculateFragmentInThread(i, x_start+i*fragmentWidth, y_start, x_start+i*fragmentWidth+fragmentWidth, fragmentHeight, i*fragmentWidth*fragmentHeight, x_end)
+// Show goes to a view of the navigation history, keeping the coloring
+// settings. Its frame is shown without rendering it again when it was kept.
+func (m *Mandelbrot) Show(entry HistoryEntry) {
+	viewport := entry.Viewport
+	viewport.Coloring = m.Viewport.Coloring
+	viewport.Palette = m.Viewport.Palette
+	m.Viewport = viewport
+	m.JuliaPreview.MouseX, m.JuliaPreview.MouseY = -1, -1
+
+	if entry.Image == nil {
+		m.Image = image.NewRGBA(m.Image.Rect)
+		m.Cluster.Iterations = nil
+		m.NeedUpdate = true
+		return
 	}
 
-	m.ThreadWaitGroup.Wait()
+	// The frame kept replaces the one cancelled, if any
+	m.Image = entry.Image
+	m.Cluster.Iterations = entry.Iterations
+	m.Stale = false
+	m.NeedUpdate = false
+	if entry.Viewport.Coloring != m.Viewport.Coloring || entry.Viewport.Palette != m.Viewport.Palette {
+		m.Recolor()
+	} else {
+		copyPixels(m.Pixels, m.Image)
+	}
 }
 
-func (m *Mandelbrot) CalculateFragmentInThread(thread_index int32, x_start int32, y_start int32, x_end int32, y_end int32, offset int32, x_region_end int32) {
-	defer m.ThreadWaitGroup.Done()
-
-	start := time.Now()
-	var red, green, blue uint8
-	var i int32 = 0
-
-	for x := x_start; (x <= x_end) && (x < x_region_end); x++ {
-		for y := y_start; y < y_end; y++ {
-			red, green, blue = m.GetPixelColorAtPosition((float64(x)/m.MagnificationFactor)-m.PanX, (float64(y)/m.MagnificationFactor)-m.PanY)
-			if m.IsMaster {
-				// RGBA buffer that will be sent to the GPU in order to draw the fractal in the screen
-				m.Pixels[(m.ScreenWidth*y)+x] = rl.NewColor(red, green, blue, 255)
-			} else {
-				// RBG buffer used to store the data that should be sent to the master node
-				m.RGBBuffer[offset*3+i*3] = red
-				m.RGBBuffer[offset*3+i*3+1] = green
-				m.RGBBuffer[offset*3+i*3+2] = blue
-				i++
-			}
-		}
+// Bookmark appends the current view to the bookmarks file.
+func (m *Mandelbrot) Bookmark() error {
+	locations, err := fractal.LoadLocations(m.Bookmarks)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	location := m.Viewport.Location(fmt.Sprintf("bookmark %d", len(locations)+1))
+	if err := fractal.SaveLocations(m.Bookmarks, append(locations, location)); err != nil {
+		return err
 	}
-	m.LocalThreadsProcessTimes[thread_index] = time.Since(start)
+	fmt.Printf("- Bookmarked the view as %q in %s\n", location.Name, m.Bookmarks)
+	return nil
 }
 
-func (m *Mandelbrot) GetPixelColorAtPosition(x float64, y float64) (uint8, uint8, uint8) {
-	realComponent := x
-	imaginaryComponent := y
-	var tempRealComponent float64
+// GoToNextBookmark moves the view to the bookmark following the last one
+// shown, the file is read every time so the bookmarks added are included.
+func (m *Mandelbrot) GoToNextBookmark() error {
+	locations, err := fractal.LoadLocations(m.Bookmarks)
+	if err != nil {
+		return err
+	}
+	if len(locations) == 0 {
+		return fmt.Errorf("no bookmarks in %s", m.Bookmarks)
+	}
 
-	for i := float64(0); i < m.MaxIterations; i++ {
-		tempRealComponent = (realComponent * realComponent) - (imaginaryComponent * imaginaryComponent) + x
-		imaginaryComponent = 2*realComponent*imaginaryComponent + y
-		realComponent = tempRealComponent
+	location := locations[m.NextBookmark%len(locations)]
+	m.NextBookmark = (m.NextBookmark + 1) % len(locations)
+	m.BeginNavigation()
+	m.Viewport.GoTo(location)
+	m.NeedUpdate = true
+	fmt.Printf("- Showing %q\n", location.Name)
+	return nil
+}
 
-		if realComponent*imaginaryComponent > 5 {
-			colorHSV := colorful.Hsv(i*360/m.MaxIterations, 0.98, 0.922) // hue bar color (Hsv)
-			return uint8(colorHSV.R * 255), uint8(colorHSV.G * 255), uint8(colorHSV.B * 255)
+// NextFormula switches the view to the formula following the current one in
+// the formulas list.
+func (m *Mandelbrot) NextFormula() {
+	current := m.Viewport.IteratedFormula().String()
+	next := 0
+	for i, formula := range formulas {
+		if formula.String() == current {
+			next = (i + 1) % len(formulas)
 		}
 	}
+	m.Viewport.Formula = formulas[next]
+}
 
-	return 0, 0, 0 //black
+// NextColoring switches the view to the coloring mode following the current
+// one in the coloring modes list.
+func (m *Mandelbrot) NextColoring() {
+	next := 0
+	for i, mode := range coloringModes {
+		if mode == m.Viewport.Coloring {
+			next = (i + 1) % len(coloringModes)
+		}
+	}
+	m.Viewport.Coloring = coloringModes[next]
 }
 
-func (m *Mandelbrot) ProcessRequestsFromMasterNode() {
-	lis, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", m.SlavePort))
-	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
+// NextPalette switches the view to the built-in palette following the
+// current one, keeping its offset and scale.
+func (m *Mandelbrot) NextPalette() {
+	current := m.Viewport.Palette
+	next := 0
+	for i, palette := range fractal.Palettes {
+		if palette.Name == current.Name {
+			next = (i + 1) % len(fractal.Palettes)
+		}
 	}
+	m.Viewport.Palette = fractal.Palettes[next].WithOffset(current.Offset).WithScale(current.Scale)
+}
 
-	fmt.Println("\nListening for Mandelbrot jobs at 0.0.0.0 on port", m.SlavePort)
-	var opts []grpc.ServerOption
-	grpcServer := grpc.NewServer(opts...)
-	slaveNodeServer := MandelbrotSlaveNodeServer{Mandelbrot: *m}
-	proto.RegisterMandelbrotSlaveNodeServer(grpcServer, &slaveNodeServer)
-	grpcServer.Serve(lis)
+// Recolor paints the current frame with the coloring settings of the
+// viewport. The frame is rendered again when the cluster cannot recolor it.
+func (m *Mandelbrot) Recolor() {
+	if !m.Cluster.Recolor(m.Viewport, m.Image) {
+		m.NeedUpdate = true
+		return
+	}
+	copyPixels(m.Pixels, m.Image)
 }
 
-// MandelbrotSlaveNodeServer functions
+// Other functions
 
-type MandelbrotSlaveNodeServer struct {
-	proto.UnimplementedMandelbrotSlaveNodeServer
-	Mandelbrot Mandelbrot
+// loadPalette returns the built-in palette or the gradient file name with the
+// given offset and scale.
+func loadPalette(name string, offset float64, scale float64) (*fractal.Palette, error) {
+	if scale <= 0 {
+		return nil, fmt.Errorf("invalid palette scale %v", scale)
+	}
+
+	palette, err := fractal.LoadPalette(name)
+	if err != nil {
+		return nil, err
+	}
+	return palette.WithOffset(offset).WithScale(scale), nil
 }
 
-func (s *MandelbrotSlaveNodeServer) CalculateRegion(ctx context.Context, request *proto.CalculateRegionRequest) (*proto.CalculateRegionResponse, error) {
-	s.Mandelbrot.MagnificationFactor = request.GetMagnificationFactor()
-	s.Mandelbrot.MaxIterations = request.GetMaxIterations()
-	s.Mandelbrot.PanX = request.GetPanX()
-	s.Mandelbrot.PanY = request.GetPanY()
-	regionWidth := request.GetWidth()
-	regionHeight := request.GetHeight()
-	regionXStart := request.GetXStart()
-	regionXEnd := request.GetXEnd()
-	regionYStart := request.GetYStart()
-	regionYEnd := request.GetYEnd()
+// coresLabel describes the cores of a slave node, when known.
+func coresLabel(cores int32) string {
+	if cores <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(", %d cores", cores)
+}
 
-	// Following memory allocation is not efficient at all in terms of performance. Need some improvements.
-	// Allocate memory for the rgb-pixel buffer used as response
-	s.Mandelbrot.RGBBuffer = make([]byte, regionWidth*regionHeight*3)
+// registerWithMasterNode joins the cluster of the master node in the
+// background, leaving it when the slave node is interrupted.
+func registerWithMasterNode(masterAddress string, address string, port int32, cores int32) {
+	fmt.Println("- Registering with master node", masterAddress)
+	ctx, leave := context.WithCancel(context.Background())
+	left := make(chan struct{})
+	go func() {
+		if err := fractal.RegisterWithMasterNode(ctx, masterAddress, address, port, cores); err != nil {
+			log.Printf("%v", err)
+		}
+		close(left)
+	}()
+
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-interrupted
+		fmt.Println("- Leaving the cluster")
+		leave()
+		<-left
+		os.Exit(0)
+	}()
+}
 
-	s.Mandelbrot.CalculateRegionLocally(regionXStart, regionYStart, regionXEnd, regionYEnd)
+// loadLocation returns the location of a location file given as
+// file.json[#name], the last location of the file when no name is given.
+func loadLocation(spec string) (fractal.Location, error) {
+	path, name := spec, ""
+	if i := strings.LastIndex(spec, "#"); i >= 0 {
+		path, name = spec[:i], spec[i+1:]
+	}
 
-	localThreadsProcessTimesInt64 := make([]int64, s.Mandelbrot.MaxLocalThreads)
-	for i := int32(0); i < s.Mandelbrot.MaxLocalThreads; i++ {
-		localThreadsProcessTimesInt64[i] = s.Mandelbrot.LocalThreadsProcessTimes[i].Nanoseconds()
+	locations, err := fractal.LoadLocations(path)
+	if err != nil {
+		return fractal.Location{}, err
+	}
+	if len(locations) == 0 {
+		return fractal.Location{}, fmt.Errorf("no locations in %s", path)
+	}
+	if len(name) == 0 {
+		return locations[len(locations)-1], nil
 	}
 
-	return &proto.CalculateRegionResponse{RGBPixels: s.Mandelbrot.RGBBuffer, ThreadsProcessTimes: localThreadsProcessTimesInt64}, nil
+	for _, location := range locations {
+		if location.Name == name {
+			return location, nil
+		}
+	}
+	return fractal.Location{}, fmt.Errorf("location %q not found in %s", name, path)
 }
 
-// Other functions
+// explicitFlags returns the names of the flags set in the command line.
+func explicitFlags(flags *flag.FlagSet) map[string]bool {
+	explicit := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+	return explicit
+}
 
 func MIN(a, b int) int {
 	if a < b {
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"github.com/gen2brain/raylib-go/raylib"
+	"math"
+)
+
+// Times the magnification factor is multiplied by every step of the mouse wheel
+const WHEEL_ZOOM_FACTOR float64 = 1.25
+
+// Pixels the mouse pointer must move with a button down to start a drag
+// instead of a click
+const DRAG_THRESHOLD float32 = 4
+
+// MouseDrag tracks the mouse pointer while a button is held down. The left
+// button pans the view (a click centers it on the point under the pointer)
+// and the right button draws the rectangle to zoom into.
+type MouseDrag struct {
+	Button int32 // Button held down, -1 when none
+	StartX float32
+	StartY float32
+	LastX  float32
+	LastY  float32
+	Moved  bool // The pointer went beyond the drag threshold
+}
+
+// ProcessMouse navigates the view with the mouse: the wheel zooms keeping the
+// point under the pointer in place, dragging with the left button pans, a
+// left click centers the point under the pointer and dragging with the right
+// button zooms into the rectangle drawn.
+func (m *Mandelbrot) ProcessMouse() {
+	mouse := rl.GetMousePosition()
+
+	if wheel := rl.GetMouseWheelMove(); wheel != 0 {
+		m.BeginNavigation()
+		m.Viewport.ZoomAt(float64(mouse.X), float64(mouse.Y), math.Pow(WHEEL_ZOOM_FACTOR, float64(wheel)))
+		m.UpdateMaxIterations()
+		m.NeedUpdate = true
+	}
+
+	for _, button := range []int32{rl.MouseLeftButton, rl.MouseRightButton} {
+		if m.Drag.Button < 0 && rl.IsMouseButtonPressed(button) {
+			m.Drag = MouseDrag{Button: button, StartX: mouse.X, StartY: mouse.Y, LastX: mouse.X, LastY: mouse.Y}
+		}
+	}
+
+	if m.Drag.Button < 0 {
+		return
+	}
+
+	if !m.Drag.Moved && math.Hypot(float64(mouse.X-m.Drag.StartX), float64(mouse.Y-m.Drag.StartY)) >= float64(DRAG_THRESHOLD) {
+		m.Drag.Moved = true
+	}
+
+	if m.Drag.Button == rl.MouseLeftButton && m.Drag.Moved && (mouse.X != m.Drag.LastX || mouse.Y != m.Drag.LastY) {
+		m.BeginNavigation()
+		m.Viewport.Drag(float64(mouse.X-m.Drag.LastX), float64(mouse.Y-m.Drag.LastY))
+		m.NeedUpdate = true
+	}
+	m.Drag.LastX, m.Drag.LastY = mouse.X, mouse.Y
+
+	if !rl.IsMouseButtonReleased(m.Drag.Button) {
+		return
+	}
+
+	switch {
+	case m.Drag.Button == rl.MouseLeftButton && !m.Drag.Moved:
+		m.BeginNavigation()
+		m.Viewport.SetCenter(m.Viewport.PreciseCoordinates(int32(mouse.X), int32(mouse.Y)))
+		m.NeedUpdate = true
+	case m.Drag.Button == rl.MouseRightButton && m.Drag.Moved:
+		m.BeginNavigation()
+		m.Viewport.ZoomToRectangle(float64(m.Drag.StartX), float64(m.Drag.StartY), float64(mouse.X), float64(mouse.Y))
+		m.UpdateMaxIterations()
+		m.NeedUpdate = true
+	}
+	m.Drag.Button = -1
+}
+
+// Draw shows the rectangle being drawn with the right button.
+func (d *MouseDrag) Draw() {
+	if d.Button != rl.MouseRightButton || !d.Moved {
+		return
+	}
+
+	x, y := int32(math.Min(float64(d.StartX), float64(d.LastX))), int32(math.Min(float64(d.StartY), float64(d.LastY)))
+	width, height := int32(math.Abs(float64(d.LastX-d.StartX))), int32(math.Abs(float64(d.LastY-d.StartY)))
+	rl.DrawRectangle(x, y, width, height, rl.Fade(rl.RayWhite, 0.2))
+	rl.DrawRectangleLines(x, y, width, height, rl.RayWhite)
+}
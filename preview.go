@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"github.com/gen2brain/raylib-go/raylib"
+	"image"
+	"mandelbrot-fractal/fractal"
+	"time"
+)
+
+// Size of the Julia set preview shown in the bottom-right corner of the window
+const PREVIEW_WIDTH int32 = 256
+const PREVIEW_HEIGHT int32 = 144
+
+// JuliaPreview renders at low resolution the Julia set of the point under
+// the mouse pointer while exploring the Mandelbrot set.
+type JuliaPreview struct {
+	Visible  bool
+	Viewport fractal.Viewport
+	Renderer *fractal.Renderer
+	Image    *image.RGBA
+	Pixels   []rl.Color
+	Canvas   rl.RenderTexture2D
+	MouseX   int32
+	MouseY   int32
+}
+
+// Init shares the threads of the renderer of the frames with the preview,
+// which is only calculated once the frame is rendered.
+func (p *JuliaPreview) Init(renderer *fractal.Renderer) {
+	p.Visible = true
+	p.Renderer = renderer
+	p.Image = image.NewRGBA(image.Rect(0, 0, int(PREVIEW_WIDTH), int(PREVIEW_HEIGHT)))
+	p.Pixels = make([]rl.Color, PREVIEW_WIDTH*PREVIEW_HEIGHT)
+	p.Canvas = rl.LoadRenderTexture(PREVIEW_WIDTH, PREVIEW_HEIGHT)
+	p.MouseX, p.MouseY = -1, -1
+}
+
+// Update recalculates the preview when the mouse pointer or the main view
+// changed. Only the Mandelbrot view has a preview.
+func (p *JuliaPreview) Update(viewport fractal.Viewport, viewportChanged bool) {
+	if !p.Visible || !viewport.IsMandelbrot() {
+		return
+	}
+
+	mouse := rl.GetMousePosition()
+	x, y := int32(mouse.X), int32(mouse.Y)
+	if x < 0 || y < 0 || x >= viewport.Width || y >= viewport.Height {
+		return
+	}
+
+	if x == p.MouseX && y == p.MouseY && !viewportChanged {
+		return
+	}
+	p.MouseX, p.MouseY = x, y
+
+	p.Viewport = viewport.JuliaViewport(x, y, PREVIEW_WIDTH, PREVIEW_HEIGHT)
+	p.Viewport.Supersampling = 0 // The preview must keep up with the mouse pointer
+	// Keep the processing times of the frame threads shown by the overlay
+	frameProcessTimes := append([]time.Duration(nil), p.Renderer.LocalThreadsProcessTimes...)
+	p.Renderer.CalculateRegionLocally(p.Viewport, p.Image, nil, p.Viewport.Bounds())
+	copy(p.Renderer.LocalThreadsProcessTimes, frameProcessTimes)
+	copyPixels(p.Pixels, p.Image)
+	rl.UpdateTexture(p.Canvas.Texture, p.Pixels)
+}
+
+// Draw shows the preview in the bottom-right corner of the window.
+func (p *JuliaPreview) Draw(screenWidth int32, screenHeight int32) {
+	if !p.Visible || p.MouseX < 0 {
+		return
+	}
+
+	x := screenWidth - PREVIEW_WIDTH - 8
+	y := screenHeight - PREVIEW_HEIGHT - 8
+	rl.DrawTexture(p.Canvas.Texture, x, y, rl.RayWhite)
+	rl.DrawRectangleLines(x-1, y-1, PREVIEW_WIDTH+2, PREVIEW_HEIGHT+2, rl.RayWhite)
+	rl.DrawText(fmt.Sprintf("Julia %s", p.Viewport.IteratedFormula()), x, y-16, 10, rl.RayWhite)
+}
+
+// copyPixels copies a rendered image to the RGBA buffer sent to the GPU.
+func copyPixels(pixels []rl.Color, img *image.RGBA) {
+	for i := range pixels {
+		pixels[i] = rl.NewColor(img.Pix[i*4], img.Pix[i*4+1], img.Pix[i*4+2], 255)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/gen2brain/raylib-go/raygui"
+	"github.com/gen2brain/raylib-go/raylib"
+	"image"
+	"mandelbrot-fractal/fractal"
+	"sync"
+	"time"
+)
+
+// Time a frame is rendered before the window starts showing its tiles as
+// they arrive, and then between every update of the window
+const PROGRESS_INTERVAL time.Duration = 50 * time.Millisecond
+
+// ArrivedTiles collects the tiles of the frame being rendered, calculated by
+// the cluster in other goroutines, until the window shows them.
+type ArrivedTiles struct {
+	mutex sync.Mutex
+	tiles []fractal.Region
+	count int // Tiles of the frame arrived so far
+}
+
+// Add collects a tile drawn into the frame, see fractal.Cluster.OnTile.
+func (a *ArrivedTiles) Add(tile fractal.Region) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.tiles = append(a.tiles, tile)
+	a.count++
+}
+
+// Take returns the tiles arrived since the last call and the number of tiles
+// of the frame arrived so far.
+func (a *ArrivedTiles) Take() ([]fractal.Region, int) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	tiles := a.tiles
+	a.tiles = nil
+	return tiles, a.count
+}
+
+// Reset forgets the tiles of the last frame.
+func (a *ArrivedTiles) Reset() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.tiles = nil
+	a.count = 0
+}
+
+// RenderProgressively renders the frame in the background. While it takes
+// long the window keeps showing the tiles calculated as they arrive, over the
+// last frame, and the frame is cancelled as soon as the input asks for a new
+// view. It returns true when the frame was cancelled, then the input polled
+// while rendering it must be processed before polling it again. A cancelled
+// frame is stale and rendered again.
+func (m *Mandelbrot) RenderProgressively() bool {
+	m.Tiles.Reset()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- m.Cluster.RenderContext(ctx, m.Viewport, m.Image)
+	}()
+
+	ticker := time.NewTicker(PROGRESS_INTERVAL)
+	defer ticker.Stop()
+	total := len(m.Viewport.Bounds().Tiles(fractal.TileSize))
+	for {
+		select {
+		case err := <-done:
+			m.FrameRendered(err)
+			return false
+		case <-ticker.C:
+			tiles, count := m.Tiles.Take()
+			for _, tile := range tiles {
+				copyTilePixels(m.Pixels, m.Image, tile)
+			}
+			m.DrawProgress(count, total)
+
+			if m.ViewChangeRequested() {
+				cancel()
+				m.FrameRendered(<-done)
+				return true
+			}
+		}
+	}
+}
+
+// FrameRendered shows the frame rendered, or the tiles arrived when it was
+// cancelled.
+func (m *Mandelbrot) FrameRendered(err error) {
+	m.Stale = err != nil
+	if m.Stale {
+		tiles, _ := m.Tiles.Take()
+		for _, tile := range tiles {
+			copyTilePixels(m.Pixels, m.Image, tile)
+		}
+		return
+	}
+
+	// Copy the rendered frame to the RGBA buffer that will be sent to the GPU
+	copyPixels(m.Pixels, m.Image)
+}
+
+// Keys that change the view while they are held down
+var viewKeysDown = []int32{rl.KeyLeft, rl.KeyRight, rl.KeyUp, rl.KeyDown, rl.KeyA, rl.KeyS, rl.KeyLeftBracket, rl.KeyRightBracket}
+
+// Keys that change the view when they are pressed
+var viewKeysPressed = []int32{rl.KeyF, rl.KeyJ, rl.KeyN, rl.KeyM, rl.KeyMinus, rl.KeyEqual, rl.KeyC, rl.KeyL, rl.KeyZ, rl.KeyX}
+
+// ViewChangeRequested reports whether the last input polled asks for a new
+// view (see ProcessKeyboard and ProcessMouse), which makes the frame being
+// rendered stale.
+func (m *Mandelbrot) ViewChangeRequested() bool {
+	for _, key := range viewKeysDown {
+		if rl.IsKeyDown(key) {
+			return true
+		}
+	}
+	for _, key := range viewKeysPressed {
+		if rl.IsKeyPressed(key) {
+			return true
+		}
+	}
+
+	if rl.GetMouseWheelMove() != 0 {
+		return true
+	}
+	for _, button := range []int32{rl.MouseLeftButton, rl.MouseRightButton} {
+		if rl.IsMouseButtonPressed(button) || rl.IsMouseButtonReleased(button) {
+			return true
+		}
+	}
+	mouse := rl.GetMousePosition()
+	return m.Drag.Button == rl.MouseLeftButton && (mouse.X != m.Drag.LastX || mouse.Y != m.Drag.LastY)
+}
+
+// DrawProgress shows the frame being rendered. The stats of the nodes are
+// updated by the render, so only the tiles arrived are shown.
+func (m *Mandelbrot) DrawProgress(tiles int, total int) {
+	rl.BeginDrawing()
+	m.DrawFrame()
+	raygui.Label(rl.NewRectangle(0, float32(m.ScreenHeight-20), 100, 14), fmt.Sprintf("(Rendering: %d/%d tiles)\n", tiles, total))
+	rl.EndDrawing()
+}
+
+// copyTilePixels copies a tile of img to the RGBA buffer of the whole frame.
+func copyTilePixels(pixels []rl.Color, img *image.RGBA, tile fractal.Region) {
+	width := int32(img.Rect.Dx())
+	for y := tile.YStart; y <= tile.YEnd; y++ {
+		for x := tile.XStart; x <= tile.XEnd; x++ {
+			offset := img.PixOffset(int(x), int(y))
+			pixels[y*width+x] = rl.NewColor(img.Pix[offset], img.Pix[offset+1], img.Pix[offset+2], 255)
+		}
+	}
+}
@@ -45,6 +45,43 @@ type CalculateRegionRequest struct {
 	YEnd                int32   `protobuf:"varint,9,opt,name=YEnd,proto3" json:"YEnd,omitempty"`
 	Width               int32   `protobuf:"varint,10,opt,name=Width,proto3" json:"Width,omitempty"`
 	Height              int32   `protobuf:"varint,11,opt,name=Height,proto3" json:"Height,omitempty"`
+	Coloring            int32   `protobuf:"varint,12,opt,name=Coloring,proto3" json:"Coloring,omitempty"`
+	BailoutRadius       float64 `protobuf:"fixed64,13,opt,name=BailoutRadius,proto3" json:"BailoutRadius,omitempty"`
+	// Arbitrary precision values of MagnificationFactor, PanX and PanY as
+	// exact hexadecimal mantissas ("0x.8p+1"), parsed with Precision bits
+	PreciseMagnificationFactor string `protobuf:"bytes,14,opt,name=PreciseMagnificationFactor,proto3" json:"PreciseMagnificationFactor,omitempty"`
+	PrecisePanX                string `protobuf:"bytes,15,opt,name=PrecisePanX,proto3" json:"PrecisePanX,omitempty"`
+	PrecisePanY                string `protobuf:"bytes,16,opt,name=PrecisePanY,proto3" json:"PrecisePanY,omitempty"`
+	Precision                  uint32 `protobuf:"varint,17,opt,name=Precision,proto3" json:"Precision,omitempty"`
+	DeepZoom                   int32  `protobuf:"varint,18,opt,name=DeepZoom,proto3" json:"DeepZoom,omitempty"`
+	// Reference orbit of the center of the frame used by the perturbation
+	ReferenceOrbitReal      []float64 `protobuf:"fixed64,19,rep,packed,name=ReferenceOrbitReal,proto3" json:"ReferenceOrbitReal,omitempty"`
+	ReferenceOrbitImaginary []float64 `protobuf:"fixed64,20,rep,packed,name=ReferenceOrbitImaginary,proto3" json:"ReferenceOrbitImaginary,omitempty"`
+	// Series approximation: iterations skipped and coefficients A, B and C of
+	// the series as real and imaginary pairs
+	SkippedIterations  int32     `protobuf:"varint,21,opt,name=SkippedIterations,proto3" json:"SkippedIterations,omitempty"`
+	SeriesCoefficients []float64 `protobuf:"fixed64,22,rep,packed,name=SeriesCoefficients,proto3" json:"SeriesCoefficients,omitempty"`
+	// Iterated formula as parsed by fractal.ParseFormula, "mandelbrot" when empty
+	Formula string `protobuf:"bytes,23,opt,name=Formula,proto3" json:"Formula,omitempty"`
+	// Gradient as encoded by fractal.Palette.MarshalJSON, the hue bar when empty
+	Palette string `protobuf:"bytes,24,opt,name=Palette,proto3" json:"Palette,omitempty"`
+	// Payload of the response, fractal.ResponseFormat: RGB pixels or
+	// iteration data colored by the master
+	ResponseFormat int32 `protobuf:"varint,25,opt,name=ResponseFormat,proto3" json:"ResponseFormat,omitempty"`
+	// Samples per side of every pixel, the pixels are the average of
+	// Supersampling x Supersampling samples
+	Supersampling int32 `protobuf:"varint,26,opt,name=Supersampling,proto3" json:"Supersampling,omitempty"`
+	// Tiles to calculate by CalculateTiles as XStart, YStart, XEnd and YEnd
+	// quadruples
+	Tiles []int32 `protobuf:"varint,27,rep,packed,name=Tiles,proto3" json:"Tiles,omitempty"`
+	// Frame of the master node the request belongs to, increasing with every
+	// frame. The slave node cancels the requests of a master node when it
+	// requests a newer frame; 0 when unknown
+	Generation uint64 `protobuf:"varint,28,opt,name=Generation,proto3" json:"Generation,omitempty"`
+	// Palette, ReferenceOrbitReal, ReferenceOrbitImaginary, SkippedIterations
+	// and SeriesCoefficients are omitted, the slave node uses the ones of the
+	// frame kept from an earlier request (see KeepsFrame)
+	CachedFrame bool `protobuf:"varint,29,opt,name=CachedFrame,proto3" json:"CachedFrame,omitempty"`
 }
 
 func (x *CalculateRegionRequest) Reset() {
@@ -156,6 +193,132 @@ func (x *CalculateRegionRequest) GetHeight() int32 {
 	return 0
 }
 
+func (x *CalculateRegionRequest) GetColoring() int32 {
+	if x != nil {
+		return x.Coloring
+	}
+	return 0
+}
+
+func (x *CalculateRegionRequest) GetBailoutRadius() float64 {
+	if x != nil {
+		return x.BailoutRadius
+	}
+	return 0
+}
+
+func (x *CalculateRegionRequest) GetPreciseMagnificationFactor() string {
+	if x != nil {
+		return x.PreciseMagnificationFactor
+	}
+	return ""
+}
+
+func (x *CalculateRegionRequest) GetPrecisePanX() string {
+	if x != nil {
+		return x.PrecisePanX
+	}
+	return ""
+}
+
+func (x *CalculateRegionRequest) GetPrecisePanY() string {
+	if x != nil {
+		return x.PrecisePanY
+	}
+	return ""
+}
+
+func (x *CalculateRegionRequest) GetPrecision() uint32 {
+	if x != nil {
+		return x.Precision
+	}
+	return 0
+}
+
+func (x *CalculateRegionRequest) GetDeepZoom() int32 {
+	if x != nil {
+		return x.DeepZoom
+	}
+	return 0
+}
+
+func (x *CalculateRegionRequest) GetReferenceOrbitReal() []float64 {
+	if x != nil {
+		return x.ReferenceOrbitReal
+	}
+	return nil
+}
+
+func (x *CalculateRegionRequest) GetReferenceOrbitImaginary() []float64 {
+	if x != nil {
+		return x.ReferenceOrbitImaginary
+	}
+	return nil
+}
+
+func (x *CalculateRegionRequest) GetSkippedIterations() int32 {
+	if x != nil {
+		return x.SkippedIterations
+	}
+	return 0
+}
+
+func (x *CalculateRegionRequest) GetSeriesCoefficients() []float64 {
+	if x != nil {
+		return x.SeriesCoefficients
+	}
+	return nil
+}
+
+func (x *CalculateRegionRequest) GetFormula() string {
+	if x != nil {
+		return x.Formula
+	}
+	return ""
+}
+
+func (x *CalculateRegionRequest) GetPalette() string {
+	if x != nil {
+		return x.Palette
+	}
+	return ""
+}
+
+func (x *CalculateRegionRequest) GetResponseFormat() int32 {
+	if x != nil {
+		return x.ResponseFormat
+	}
+	return 0
+}
+
+func (x *CalculateRegionRequest) GetSupersampling() int32 {
+	if x != nil {
+		return x.Supersampling
+	}
+	return 0
+}
+
+func (x *CalculateRegionRequest) GetTiles() []int32 {
+	if x != nil {
+		return x.Tiles
+	}
+	return nil
+}
+
+func (x *CalculateRegionRequest) GetGeneration() uint64 {
+	if x != nil {
+		return x.Generation
+	}
+	return 0
+}
+
+func (x *CalculateRegionRequest) GetCachedFrame() bool {
+	if x != nil {
+		return x.CachedFrame
+	}
+	return false
+}
+
 type CalculateRegionResponse struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -163,6 +326,23 @@ type CalculateRegionResponse struct {
 
 	RGBPixels           []byte  `protobuf:"bytes,1,opt,name=RGBPixels,proto3" json:"RGBPixels,omitempty"`
 	ThreadsProcessTimes []int64 `protobuf:"varint,2,rep,packed,name=ThreadsProcessTimes,proto3" json:"ThreadsProcessTimes,omitempty"`
+	// Row-major iteration counts and final |z| of the pixels of the region,
+	// sent instead of RGBPixels when the request asks for iteration data. With
+	// supersampling the samples of every pixel are consecutive
+	Iterations []float32 `protobuf:"fixed32,3,rep,packed,name=Iterations,proto3" json:"Iterations,omitempty"`
+	Modulus    []float32 `protobuf:"fixed32,4,rep,packed,name=Modulus,proto3" json:"Modulus,omitempty"`
+	// Row-major distance to the set in pixels, only when the coloring mode
+	// of the request estimates it
+	Distance []float32 `protobuf:"fixed32,5,rep,packed,name=Distance,proto3" json:"Distance,omitempty"`
+	// Tile of the response, streamed by CalculateTiles
+	XStart int32 `protobuf:"varint,6,opt,name=XStart,proto3" json:"XStart,omitempty"`
+	YStart int32 `protobuf:"varint,7,opt,name=YStart,proto3" json:"YStart,omitempty"`
+	XEnd   int32 `protobuf:"varint,8,opt,name=XEnd,proto3" json:"XEnd,omitempty"`
+	YEnd   int32 `protobuf:"varint,9,opt,name=YEnd,proto3" json:"YEnd,omitempty"`
+	// The slave node keeps the palette and the reference orbit of the frame of
+	// the request, the next requests of the frame can omit them (see
+	// CachedFrame). Slave nodes of older versions don't keep them
+	KeepsFrame bool `protobuf:"varint,10,opt,name=KeepsFrame,proto3" json:"KeepsFrame,omitempty"`
 }
 
 func (x *CalculateRegionResponse) Reset() {
@@ -211,11 +391,284 @@ func (x *CalculateRegionResponse) GetThreadsProcessTimes() []int64 {
 	return nil
 }
 
+func (x *CalculateRegionResponse) GetIterations() []float32 {
+	if x != nil {
+		return x.Iterations
+	}
+	return nil
+}
+
+func (x *CalculateRegionResponse) GetModulus() []float32 {
+	if x != nil {
+		return x.Modulus
+	}
+	return nil
+}
+
+func (x *CalculateRegionResponse) GetDistance() []float32 {
+	if x != nil {
+		return x.Distance
+	}
+	return nil
+}
+
+func (x *CalculateRegionResponse) GetXStart() int32 {
+	if x != nil {
+		return x.XStart
+	}
+	return 0
+}
+
+func (x *CalculateRegionResponse) GetYStart() int32 {
+	if x != nil {
+		return x.YStart
+	}
+	return 0
+}
+
+func (x *CalculateRegionResponse) GetXEnd() int32 {
+	if x != nil {
+		return x.XEnd
+	}
+	return 0
+}
+
+func (x *CalculateRegionResponse) GetYEnd() int32 {
+	if x != nil {
+		return x.YEnd
+	}
+	return 0
+}
+
+func (x *CalculateRegionResponse) GetKeepsFrame() bool {
+	if x != nil {
+		return x.KeepsFrame
+	}
+	return false
+}
+
+type RegisterRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Address where the slave node serves the regions, the address the request
+	// comes from when empty
+	Address string `protobuf:"bytes,1,opt,name=Address,proto3" json:"Address,omitempty"`
+	Port    int32  `protobuf:"varint,2,opt,name=Port,proto3" json:"Port,omitempty"`
+	Cores   int32  `protobuf:"varint,3,opt,name=Cores,proto3" json:"Cores,omitempty"`
+}
+
+func (x *RegisterRequest) Reset() {
+	*x = RegisterRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mandelbrot_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RegisterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterRequest) ProtoMessage() {}
+
+func (x *RegisterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mandelbrot_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterRequest.ProtoReflect.Descriptor instead.
+func (*RegisterRequest) Descriptor() ([]byte, []int) {
+	return file_mandelbrot_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RegisterRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *RegisterRequest) GetPort() int32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+func (x *RegisterRequest) GetCores() int32 {
+	if x != nil {
+		return x.Cores
+	}
+	return 0
+}
+
+type RegisterResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Nanoseconds between heartbeats
+	HeartbeatInterval int64 `protobuf:"varint,1,opt,name=HeartbeatInterval,proto3" json:"HeartbeatInterval,omitempty"`
+}
+
+func (x *RegisterResponse) Reset() {
+	*x = RegisterResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mandelbrot_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RegisterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterResponse) ProtoMessage() {}
+
+func (x *RegisterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mandelbrot_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterResponse.ProtoReflect.Descriptor instead.
+func (*RegisterResponse) Descriptor() ([]byte, []int) {
+	return file_mandelbrot_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *RegisterResponse) GetHeartbeatInterval() int64 {
+	if x != nil {
+		return x.HeartbeatInterval
+	}
+	return 0
+}
+
+type HeartbeatRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Address string `protobuf:"bytes,1,opt,name=Address,proto3" json:"Address,omitempty"`
+	Port    int32  `protobuf:"varint,2,opt,name=Port,proto3" json:"Port,omitempty"`
+}
+
+func (x *HeartbeatRequest) Reset() {
+	*x = HeartbeatRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mandelbrot_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HeartbeatRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatRequest) ProtoMessage() {}
+
+func (x *HeartbeatRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mandelbrot_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatRequest.ProtoReflect.Descriptor instead.
+func (*HeartbeatRequest) Descriptor() ([]byte, []int) {
+	return file_mandelbrot_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *HeartbeatRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *HeartbeatRequest) GetPort() int32 {
+	if x != nil {
+		return x.Port
+	}
+	return 0
+}
+
+type HeartbeatResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// False when the master node doesn't know the slave node, which must
+	// register again
+	Registered bool `protobuf:"varint,1,opt,name=Registered,proto3" json:"Registered,omitempty"`
+}
+
+func (x *HeartbeatResponse) Reset() {
+	*x = HeartbeatResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_mandelbrot_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *HeartbeatResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HeartbeatResponse) ProtoMessage() {}
+
+func (x *HeartbeatResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mandelbrot_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HeartbeatResponse.ProtoReflect.Descriptor instead.
+func (*HeartbeatResponse) Descriptor() ([]byte, []int) {
+	return file_mandelbrot_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *HeartbeatResponse) GetRegistered() bool {
+	if x != nil {
+		return x.Registered
+	}
+	return false
+}
+
 var File_mandelbrot_proto protoreflect.FileDescriptor
 
 var file_mandelbrot_proto_rawDesc = []byte{
 	0x0a, 0x10, 0x6d, 0x61, 0x6e, 0x64, 0x65, 0x6c, 0x62, 0x72, 0x6f, 0x74, 0x2e, 0x70, 0x72, 0x6f,
-	0x74, 0x6f, 0x12, 0x05, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xb4, 0x02, 0x0a, 0x16, 0x43, 0x61,
+	0x74, 0x6f, 0x12, 0x05, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xe6, 0x07, 0x0a, 0x16, 0x43, 0x61,
 	0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x52, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x71,
 	0x75, 0x65, 0x73, 0x74, 0x12, 0x30, 0x0a, 0x13, 0x4d, 0x61, 0x67, 0x6e, 0x69, 0x66, 0x69, 0x63,
 	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x46, 0x61, 0x63, 0x74, 0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28,
@@ -235,19 +688,112 @@ var file_mandelbrot_proto_rawDesc = []byte{
 	0x6e, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x57, 0x69, 0x64, 0x74, 0x68, 0x18, 0x0a, 0x20, 0x01, 0x28,
 	0x05, 0x52, 0x05, 0x57, 0x69, 0x64, 0x74, 0x68, 0x12, 0x16, 0x0a, 0x06, 0x48, 0x65, 0x69, 0x67,
 	0x68, 0x74, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x48, 0x65, 0x69, 0x67, 0x68, 0x74,
-	0x22, 0x6d, 0x0a, 0x17, 0x43, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x52, 0x65, 0x67,
-	0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x52,
-	0x47, 0x42, 0x50, 0x69, 0x78, 0x65, 0x6c, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x09,
-	0x52, 0x47, 0x42, 0x50, 0x69, 0x78, 0x65, 0x6c, 0x73, 0x12, 0x34, 0x0a, 0x13, 0x54, 0x68, 0x72,
-	0x65, 0x61, 0x64, 0x73, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x54, 0x69, 0x6d, 0x65, 0x73,
-	0x18, 0x02, 0x20, 0x03, 0x28, 0x03, 0x42, 0x02, 0x10, 0x01, 0x52, 0x13, 0x54, 0x68, 0x72, 0x65,
-	0x61, 0x64, 0x73, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x32,
-	0x69, 0x0a, 0x13, 0x4d, 0x61, 0x6e, 0x64, 0x65, 0x6c, 0x62, 0x72, 0x6f, 0x74, 0x53, 0x6c, 0x61,
-	0x76, 0x65, 0x4e, 0x6f, 0x64, 0x65, 0x12, 0x52, 0x0a, 0x0f, 0x43, 0x61, 0x6c, 0x63, 0x75, 0x6c,
-	0x61, 0x74, 0x65, 0x52, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x12, 0x1d, 0x2e, 0x70, 0x72, 0x6f, 0x74,
+	0x12, 0x1a, 0x0a, 0x08, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x69, 0x6e, 0x67, 0x18, 0x0c, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x08, 0x43, 0x6f, 0x6c, 0x6f, 0x72, 0x69, 0x6e, 0x67, 0x12, 0x24, 0x0a, 0x0d,
+	0x42, 0x61, 0x69, 0x6c, 0x6f, 0x75, 0x74, 0x52, 0x61, 0x64, 0x69, 0x75, 0x73, 0x18, 0x0d, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x0d, 0x42, 0x61, 0x69, 0x6c, 0x6f, 0x75, 0x74, 0x52, 0x61, 0x64, 0x69,
+	0x75, 0x73, 0x12, 0x3e, 0x0a, 0x1a, 0x50, 0x72, 0x65, 0x63, 0x69, 0x73, 0x65, 0x4d, 0x61, 0x67,
+	0x6e, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x46, 0x61, 0x63, 0x74, 0x6f, 0x72,
+	0x18, 0x0e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x1a, 0x50, 0x72, 0x65, 0x63, 0x69, 0x73, 0x65, 0x4d,
+	0x61, 0x67, 0x6e, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x46, 0x61, 0x63, 0x74,
+	0x6f, 0x72, 0x12, 0x20, 0x0a, 0x0b, 0x50, 0x72, 0x65, 0x63, 0x69, 0x73, 0x65, 0x50, 0x61, 0x6e,
+	0x58, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x50, 0x72, 0x65, 0x63, 0x69, 0x73, 0x65,
+	0x50, 0x61, 0x6e, 0x58, 0x12, 0x20, 0x0a, 0x0b, 0x50, 0x72, 0x65, 0x63, 0x69, 0x73, 0x65, 0x50,
+	0x61, 0x6e, 0x59, 0x18, 0x10, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x50, 0x72, 0x65, 0x63, 0x69,
+	0x73, 0x65, 0x50, 0x61, 0x6e, 0x59, 0x12, 0x1c, 0x0a, 0x09, 0x50, 0x72, 0x65, 0x63, 0x69, 0x73,
+	0x69, 0x6f, 0x6e, 0x18, 0x11, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x50, 0x72, 0x65, 0x63, 0x69,
+	0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x44, 0x65, 0x65, 0x70, 0x5a, 0x6f, 0x6f, 0x6d,
+	0x18, 0x12, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x44, 0x65, 0x65, 0x70, 0x5a, 0x6f, 0x6f, 0x6d,
+	0x12, 0x32, 0x0a, 0x12, 0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x4f, 0x72, 0x62,
+	0x69, 0x74, 0x52, 0x65, 0x61, 0x6c, 0x18, 0x13, 0x20, 0x03, 0x28, 0x01, 0x42, 0x02, 0x10, 0x01,
+	0x52, 0x12, 0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63, 0x65, 0x4f, 0x72, 0x62, 0x69, 0x74,
+	0x52, 0x65, 0x61, 0x6c, 0x12, 0x3c, 0x0a, 0x17, 0x52, 0x65, 0x66, 0x65, 0x72, 0x65, 0x6e, 0x63,
+	0x65, 0x4f, 0x72, 0x62, 0x69, 0x74, 0x49, 0x6d, 0x61, 0x67, 0x69, 0x6e, 0x61, 0x72, 0x79, 0x18,
+	0x14, 0x20, 0x03, 0x28, 0x01, 0x42, 0x02, 0x10, 0x01, 0x52, 0x17, 0x52, 0x65, 0x66, 0x65, 0x72,
+	0x65, 0x6e, 0x63, 0x65, 0x4f, 0x72, 0x62, 0x69, 0x74, 0x49, 0x6d, 0x61, 0x67, 0x69, 0x6e, 0x61,
+	0x72, 0x79, 0x12, 0x2c, 0x0a, 0x11, 0x53, 0x6b, 0x69, 0x70, 0x70, 0x65, 0x64, 0x49, 0x74, 0x65,
+	0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x15, 0x20, 0x01, 0x28, 0x05, 0x52, 0x11, 0x53,
+	0x6b, 0x69, 0x70, 0x70, 0x65, 0x64, 0x49, 0x74, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x12, 0x32, 0x0a, 0x12, 0x53, 0x65, 0x72, 0x69, 0x65, 0x73, 0x43, 0x6f, 0x65, 0x66, 0x66, 0x69,
+	0x63, 0x69, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x16, 0x20, 0x03, 0x28, 0x01, 0x42, 0x02, 0x10, 0x01,
+	0x52, 0x12, 0x53, 0x65, 0x72, 0x69, 0x65, 0x73, 0x43, 0x6f, 0x65, 0x66, 0x66, 0x69, 0x63, 0x69,
+	0x65, 0x6e, 0x74, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x46, 0x6f, 0x72, 0x6d, 0x75, 0x6c, 0x61, 0x18,
+	0x17, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x46, 0x6f, 0x72, 0x6d, 0x75, 0x6c, 0x61, 0x12, 0x18,
+	0x0a, 0x07, 0x50, 0x61, 0x6c, 0x65, 0x74, 0x74, 0x65, 0x18, 0x18, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x50, 0x61, 0x6c, 0x65, 0x74, 0x74, 0x65, 0x12, 0x26, 0x0a, 0x0e, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74, 0x18, 0x19, 0x20, 0x01, 0x28, 0x05,
+	0x52, 0x0e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x46, 0x6f, 0x72, 0x6d, 0x61, 0x74,
+	0x12, 0x24, 0x0a, 0x0d, 0x53, 0x75, 0x70, 0x65, 0x72, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x69, 0x6e,
+	0x67, 0x18, 0x1a, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0d, 0x53, 0x75, 0x70, 0x65, 0x72, 0x73, 0x61,
+	0x6d, 0x70, 0x6c, 0x69, 0x6e, 0x67, 0x12, 0x18, 0x0a, 0x05, 0x54, 0x69, 0x6c, 0x65, 0x73, 0x18,
+	0x1b, 0x20, 0x03, 0x28, 0x05, 0x42, 0x02, 0x10, 0x01, 0x52, 0x05, 0x54, 0x69, 0x6c, 0x65, 0x73,
+	0x12, 0x1e, 0x0a, 0x0a, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x1c,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x0a, 0x47, 0x65, 0x6e, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x12, 0x20, 0x0a, 0x0b, 0x43, 0x61, 0x63, 0x68, 0x65, 0x64, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x18,
+	0x1d, 0x20, 0x01, 0x28, 0x08, 0x52, 0x0b, 0x43, 0x61, 0x63, 0x68, 0x65, 0x64, 0x46, 0x72, 0x61,
+	0x6d, 0x65, 0x22, 0xc7, 0x02, 0x0a, 0x17, 0x43, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x65,
+	0x52, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1c,
+	0x0a, 0x09, 0x52, 0x47, 0x42, 0x50, 0x69, 0x78, 0x65, 0x6c, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0c, 0x52, 0x09, 0x52, 0x47, 0x42, 0x50, 0x69, 0x78, 0x65, 0x6c, 0x73, 0x12, 0x34, 0x0a, 0x13,
+	0x54, 0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x54, 0x69,
+	0x6d, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x03, 0x42, 0x02, 0x10, 0x01, 0x52, 0x13, 0x54,
+	0x68, 0x72, 0x65, 0x61, 0x64, 0x73, 0x50, 0x72, 0x6f, 0x63, 0x65, 0x73, 0x73, 0x54, 0x69, 0x6d,
+	0x65, 0x73, 0x12, 0x22, 0x0a, 0x0a, 0x49, 0x74, 0x65, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x18, 0x03, 0x20, 0x03, 0x28, 0x02, 0x42, 0x02, 0x10, 0x01, 0x52, 0x0a, 0x49, 0x74, 0x65, 0x72,
+	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1c, 0x0a, 0x07, 0x4d, 0x6f, 0x64, 0x75, 0x6c, 0x75,
+	0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x02, 0x42, 0x02, 0x10, 0x01, 0x52, 0x07, 0x4d, 0x6f, 0x64,
+	0x75, 0x6c, 0x75, 0x73, 0x12, 0x1e, 0x0a, 0x08, 0x44, 0x69, 0x73, 0x74, 0x61, 0x6e, 0x63, 0x65,
+	0x18, 0x05, 0x20, 0x03, 0x28, 0x02, 0x42, 0x02, 0x10, 0x01, 0x52, 0x08, 0x44, 0x69, 0x73, 0x74,
+	0x61, 0x6e, 0x63, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x58, 0x53, 0x74, 0x61, 0x72, 0x74, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x58, 0x53, 0x74, 0x61, 0x72, 0x74, 0x12, 0x16, 0x0a, 0x06,
+	0x59, 0x53, 0x74, 0x61, 0x72, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x59, 0x53,
+	0x74, 0x61, 0x72, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x58, 0x45, 0x6e, 0x64, 0x18, 0x08, 0x20, 0x01,
+	0x28, 0x05, 0x52, 0x04, 0x58, 0x45, 0x6e, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x59, 0x45, 0x6e, 0x64,
+	0x18, 0x09, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x59, 0x45, 0x6e, 0x64, 0x12, 0x1e, 0x0a, 0x0a,
+	0x4b, 0x65, 0x65, 0x70, 0x73, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x0a, 0x4b, 0x65, 0x65, 0x70, 0x73, 0x46, 0x72, 0x61, 0x6d, 0x65, 0x22, 0x55, 0x0a, 0x0f,
+	0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x18, 0x0a, 0x07, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x07, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x50, 0x6f, 0x72,
+	0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x50, 0x6f, 0x72, 0x74, 0x12, 0x14, 0x0a,
+	0x05, 0x43, 0x6f, 0x72, 0x65, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x43, 0x6f,
+	0x72, 0x65, 0x73, 0x22, 0x40, 0x0a, 0x10, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2c, 0x0a, 0x11, 0x48, 0x65, 0x61, 0x72, 0x74,
+	0x62, 0x65, 0x61, 0x74, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x11, 0x48, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x49, 0x6e, 0x74,
+	0x65, 0x72, 0x76, 0x61, 0x6c, 0x22, 0x40, 0x0a, 0x10, 0x48, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65,
+	0x61, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x41, 0x64, 0x64,
+	0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x41, 0x64, 0x64, 0x72,
+	0x65, 0x73, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x50, 0x6f, 0x72, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x05, 0x52, 0x04, 0x50, 0x6f, 0x72, 0x74, 0x22, 0x33, 0x0a, 0x11, 0x48, 0x65, 0x61, 0x72, 0x74,
+	0x62, 0x65, 0x61, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1e, 0x0a, 0x0a,
+	0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x65, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x08,
+	0x52, 0x0a, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x65, 0x64, 0x32, 0xbe, 0x01, 0x0a,
+	0x13, 0x4d, 0x61, 0x6e, 0x64, 0x65, 0x6c, 0x62, 0x72, 0x6f, 0x74, 0x53, 0x6c, 0x61, 0x76, 0x65,
+	0x4e, 0x6f, 0x64, 0x65, 0x12, 0x52, 0x0a, 0x0f, 0x43, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74,
+	0x65, 0x52, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x12, 0x1d, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e,
+	0x43, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x52, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x43,
+	0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x52, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x53, 0x0a, 0x0e, 0x43, 0x61, 0x6c, 0x63,
+	0x75, 0x6c, 0x61, 0x74, 0x65, 0x54, 0x69, 0x6c, 0x65, 0x73, 0x12, 0x1d, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x43, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x52, 0x65, 0x67, 0x69,
+	0x6f, 0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x70, 0x72, 0x6f, 0x74,
 	0x6f, 0x2e, 0x43, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x52, 0x65, 0x67, 0x69, 0x6f,
-	0x6e, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x2e, 0x43, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x52, 0x65, 0x67, 0x69, 0x6f, 0x6e,
+	0x6e, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x30, 0x01, 0x32, 0xda, 0x01,
+	0x0a, 0x14, 0x4d, 0x61, 0x6e, 0x64, 0x65, 0x6c, 0x62, 0x72, 0x6f, 0x74, 0x4d, 0x61, 0x73, 0x74,
+	0x65, 0x72, 0x4e, 0x6f, 0x64, 0x65, 0x12, 0x3d, 0x0a, 0x08, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74,
+	0x65, 0x72, 0x12, 0x16, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73,
+	0x74, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x2e, 0x52, 0x65, 0x67, 0x69, 0x73, 0x74, 0x65, 0x72, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x40, 0x0a, 0x09, 0x48, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65,
+	0x61, 0x74, 0x12, 0x17, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x48, 0x65, 0x61, 0x72, 0x74,
+	0x62, 0x65, 0x61, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18, 0x2e, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x2e, 0x48, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x12, 0x41, 0x0a, 0x0a, 0x55, 0x6e, 0x72, 0x65, 0x67,
+	0x69, 0x73, 0x74, 0x65, 0x72, 0x12, 0x17, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x48, 0x65,
+	0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x18,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2e, 0x48, 0x65, 0x61, 0x72, 0x74, 0x62, 0x65, 0x61, 0x74,
 	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x00, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74,
 	0x6f, 0x33,
 }
@@ -264,16 +810,28 @@ func file_mandelbrot_proto_rawDescGZIP() []byte {
 	return file_mandelbrot_proto_rawDescData
 }
 
-var file_mandelbrot_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_mandelbrot_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
 var file_mandelbrot_proto_goTypes = []interface{}{
 	(*CalculateRegionRequest)(nil),  // 0: proto.CalculateRegionRequest
 	(*CalculateRegionResponse)(nil), // 1: proto.CalculateRegionResponse
+	(*RegisterRequest)(nil),         // 2: proto.RegisterRequest
+	(*RegisterResponse)(nil),        // 3: proto.RegisterResponse
+	(*HeartbeatRequest)(nil),        // 4: proto.HeartbeatRequest
+	(*HeartbeatResponse)(nil),       // 5: proto.HeartbeatResponse
 }
 var file_mandelbrot_proto_depIdxs = []int32{
 	0, // 0: proto.MandelbrotSlaveNode.CalculateRegion:input_type -> proto.CalculateRegionRequest
-	1, // 1: proto.MandelbrotSlaveNode.CalculateRegion:output_type -> proto.CalculateRegionResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
+	0, // 1: proto.MandelbrotSlaveNode.CalculateTiles:input_type -> proto.CalculateRegionRequest
+	2, // 2: proto.MandelbrotMasterNode.Register:input_type -> proto.RegisterRequest
+	4, // 3: proto.MandelbrotMasterNode.Heartbeat:input_type -> proto.HeartbeatRequest
+	4, // 4: proto.MandelbrotMasterNode.Unregister:input_type -> proto.HeartbeatRequest
+	1, // 5: proto.MandelbrotSlaveNode.CalculateRegion:output_type -> proto.CalculateRegionResponse
+	1, // 6: proto.MandelbrotSlaveNode.CalculateTiles:output_type -> proto.CalculateRegionResponse
+	3, // 7: proto.MandelbrotMasterNode.Register:output_type -> proto.RegisterResponse
+	5, // 8: proto.MandelbrotMasterNode.Heartbeat:output_type -> proto.HeartbeatResponse
+	5, // 9: proto.MandelbrotMasterNode.Unregister:output_type -> proto.HeartbeatResponse
+	5, // [5:10] is the sub-list for method output_type
+	0, // [0:5] is the sub-list for method input_type
 	0, // [0:0] is the sub-list for extension type_name
 	0, // [0:0] is the sub-list for extension extendee
 	0, // [0:0] is the sub-list for field type_name
@@ -309,6 +867,54 @@ func file_mandelbrot_proto_init() {
 				return nil
 			}
 		}
+		file_mandelbrot_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RegisterRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mandelbrot_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*RegisterResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mandelbrot_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HeartbeatRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_mandelbrot_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*HeartbeatResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -316,9 +922,9 @@ func file_mandelbrot_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_mandelbrot_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   6,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   2,
 		},
 		GoTypes:           file_mandelbrot_proto_goTypes,
 		DependencyIndexes: file_mandelbrot_proto_depIdxs,
@@ -343,6 +949,9 @@ const _ = grpc.SupportPackageIsVersion6
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type MandelbrotSlaveNodeClient interface {
 	CalculateRegion(ctx context.Context, in *CalculateRegionRequest, opts ...grpc.CallOption) (*CalculateRegionResponse, error)
+	// Calculates the Tiles of the request one after another and streams every
+	// tile as soon as it is calculated, the region of the request is ignored
+	CalculateTiles(ctx context.Context, in *CalculateRegionRequest, opts ...grpc.CallOption) (MandelbrotSlaveNode_CalculateTilesClient, error)
 }
 
 type mandelbrotSlaveNodeClient struct {
@@ -362,9 +971,44 @@ func (c *mandelbrotSlaveNodeClient) CalculateRegion(ctx context.Context, in *Cal
 	return out, nil
 }
 
+func (c *mandelbrotSlaveNodeClient) CalculateTiles(ctx context.Context, in *CalculateRegionRequest, opts ...grpc.CallOption) (MandelbrotSlaveNode_CalculateTilesClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_MandelbrotSlaveNode_serviceDesc.Streams[0], "/proto.MandelbrotSlaveNode/CalculateTiles", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &mandelbrotSlaveNodeCalculateTilesClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type MandelbrotSlaveNode_CalculateTilesClient interface {
+	Recv() (*CalculateRegionResponse, error)
+	grpc.ClientStream
+}
+
+type mandelbrotSlaveNodeCalculateTilesClient struct {
+	grpc.ClientStream
+}
+
+func (x *mandelbrotSlaveNodeCalculateTilesClient) Recv() (*CalculateRegionResponse, error) {
+	m := new(CalculateRegionResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // MandelbrotSlaveNodeServer is the server API for MandelbrotSlaveNode service.
 type MandelbrotSlaveNodeServer interface {
 	CalculateRegion(context.Context, *CalculateRegionRequest) (*CalculateRegionResponse, error)
+	// Calculates the Tiles of the request one after another and streams every
+	// tile as soon as it is calculated, the region of the request is ignored
+	CalculateTiles(*CalculateRegionRequest, MandelbrotSlaveNode_CalculateTilesServer) error
 }
 
 // UnimplementedMandelbrotSlaveNodeServer can be embedded to have forward compatible implementations.
@@ -374,6 +1018,9 @@ type UnimplementedMandelbrotSlaveNodeServer struct {
 func (*UnimplementedMandelbrotSlaveNodeServer) CalculateRegion(context.Context, *CalculateRegionRequest) (*CalculateRegionResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CalculateRegion not implemented")
 }
+func (*UnimplementedMandelbrotSlaveNodeServer) CalculateTiles(*CalculateRegionRequest, MandelbrotSlaveNode_CalculateTilesServer) error {
+	return status.Errorf(codes.Unimplemented, "method CalculateTiles not implemented")
+}
 
 func RegisterMandelbrotSlaveNodeServer(s *grpc.Server, srv MandelbrotSlaveNodeServer) {
 	s.RegisterService(&_MandelbrotSlaveNode_serviceDesc, srv)
@@ -397,6 +1044,27 @@ func _MandelbrotSlaveNode_CalculateRegion_Handler(srv interface{}, ctx context.C
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MandelbrotSlaveNode_CalculateTiles_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(CalculateRegionRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MandelbrotSlaveNodeServer).CalculateTiles(m, &mandelbrotSlaveNodeCalculateTilesServer{stream})
+}
+
+type MandelbrotSlaveNode_CalculateTilesServer interface {
+	Send(*CalculateRegionResponse) error
+	grpc.ServerStream
+}
+
+type mandelbrotSlaveNodeCalculateTilesServer struct {
+	grpc.ServerStream
+}
+
+func (x *mandelbrotSlaveNodeCalculateTilesServer) Send(m *CalculateRegionResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _MandelbrotSlaveNode_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "proto.MandelbrotSlaveNode",
 	HandlerType: (*MandelbrotSlaveNodeServer)(nil),
@@ -406,6 +1074,156 @@ var _MandelbrotSlaveNode_serviceDesc = grpc.ServiceDesc{
 			Handler:    _MandelbrotSlaveNode_CalculateRegion_Handler,
 		},
 	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "CalculateTiles",
+			Handler:       _MandelbrotSlaveNode_CalculateTiles_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "mandelbrot.proto",
+}
+
+// MandelbrotMasterNodeClient is the client API for MandelbrotMasterNode service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type MandelbrotMasterNodeClient interface {
+	Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error)
+	Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error)
+	Unregister(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error)
+}
+
+type mandelbrotMasterNodeClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMandelbrotMasterNodeClient(cc grpc.ClientConnInterface) MandelbrotMasterNodeClient {
+	return &mandelbrotMasterNodeClient{cc}
+}
+
+func (c *mandelbrotMasterNodeClient) Register(ctx context.Context, in *RegisterRequest, opts ...grpc.CallOption) (*RegisterResponse, error) {
+	out := new(RegisterResponse)
+	err := c.cc.Invoke(ctx, "/proto.MandelbrotMasterNode/Register", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mandelbrotMasterNodeClient) Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error) {
+	out := new(HeartbeatResponse)
+	err := c.cc.Invoke(ctx, "/proto.MandelbrotMasterNode/Heartbeat", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mandelbrotMasterNodeClient) Unregister(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error) {
+	out := new(HeartbeatResponse)
+	err := c.cc.Invoke(ctx, "/proto.MandelbrotMasterNode/Unregister", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MandelbrotMasterNodeServer is the server API for MandelbrotMasterNode service.
+type MandelbrotMasterNodeServer interface {
+	Register(context.Context, *RegisterRequest) (*RegisterResponse, error)
+	Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error)
+	Unregister(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error)
+}
+
+// UnimplementedMandelbrotMasterNodeServer can be embedded to have forward compatible implementations.
+type UnimplementedMandelbrotMasterNodeServer struct {
+}
+
+func (*UnimplementedMandelbrotMasterNodeServer) Register(context.Context, *RegisterRequest) (*RegisterResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Register not implemented")
+}
+func (*UnimplementedMandelbrotMasterNodeServer) Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Heartbeat not implemented")
+}
+func (*UnimplementedMandelbrotMasterNodeServer) Unregister(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Unregister not implemented")
+}
+
+func RegisterMandelbrotMasterNodeServer(s *grpc.Server, srv MandelbrotMasterNodeServer) {
+	s.RegisterService(&_MandelbrotMasterNode_serviceDesc, srv)
+}
+
+func _MandelbrotMasterNode_Register_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegisterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MandelbrotMasterNodeServer).Register(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.MandelbrotMasterNode/Register",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MandelbrotMasterNodeServer).Register(ctx, req.(*RegisterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MandelbrotMasterNode_Heartbeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeartbeatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MandelbrotMasterNodeServer).Heartbeat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.MandelbrotMasterNode/Heartbeat",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MandelbrotMasterNodeServer).Heartbeat(ctx, req.(*HeartbeatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MandelbrotMasterNode_Unregister_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeartbeatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MandelbrotMasterNodeServer).Unregister(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.MandelbrotMasterNode/Unregister",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MandelbrotMasterNodeServer).Unregister(ctx, req.(*HeartbeatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _MandelbrotMasterNode_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "proto.MandelbrotMasterNode",
+	HandlerType: (*MandelbrotMasterNodeServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Register",
+			Handler:    _MandelbrotMasterNode_Register_Handler,
+		},
+		{
+			MethodName: "Heartbeat",
+			Handler:    _MandelbrotMasterNode_Heartbeat_Handler,
+		},
+		{
+			MethodName: "Unregister",
+			Handler:    _MandelbrotMasterNode_Unregister_Handler,
+		},
+	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "mandelbrot.proto",
 }
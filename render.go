@@ -0,0 +1,214 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"mandelbrot-fractal/fractal"
+	"math"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// Headless rendering of a single frame into a PNG file, no window is opened.
+//
+// go run . render --center=-0.5,0 --zoom=250 --iterations=200 --coloring=smooth --output=mandelbrot.png
+// go run . render --center=-0.7436,0.1318 --zoom=1e6 --iterations=1000 --coloring=boundary --output=filaments.png
+// go run . render --palette=ultrafractal --palette-scale=4 --coloring=smooth --output=mandelbrot.png
+// go run . render --center=-0.7436,0.1318 --zoom=1e5 --iterations=1000 --supersampling=3 --output=antialiased.png
+// go run . render --location=bookmarks.json#seahorse --width=3840 --height=2160 --output=seahorse.png
+// go run . render --slaves=192.16.0.2,192.16.0.3 --output=mandelbrot.png
+
+func runRenderCommand(args []string) error {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	center := flags.String("center", "-0.5,0", "point of the complex plane at the center of the image: `x,y`")
+	zoom := flags.String("zoom", "250", "magnification factor (pixels per unit of the complex plane), with all its digits")
+	maxIterations := flags.Float64("iterations", 80, "max iterations per pixel")
+	width := flags.Int("width", int(SCREEN_WIDTH), "image width in pixels")
+	height := flags.Int("height", int(SCREEN_HEIGHT), "image height in pixels")
+	output := flags.String("output", "mandelbrot.png", "output PNG file")
+	coloring := flags.String("coloring", "iterations", "coloring mode: `iterations`, smooth, histogram, distance or boundary")
+	bailout := flags.Float64("bailout", fractal.DefaultBailoutRadius, "escape radius of the orbits")
+	deepZoom := flags.String("deep-zoom", "perturbation", "deep zoom calculation: `perturbation` or `arbitrary` precision")
+	formula := flags.String("formula", "mandelbrot", "iterated formula: `mandelbrot`, julia:<c real>,<c imaginary>, burningship, tricorn or multibrot:<power>")
+	palette := flags.String("palette", "hue", "built-in palette (hue, ultrafractal, fire, ocean or grayscale) or gradient file (.json or .map)")
+	paletteOffset := flags.Float64("palette-offset", 0, "shift of the palette within [0, 1)")
+	paletteScale := flags.Float64("palette-scale", 1, "times the palette repeats from 0 to the max iterations")
+	location := flags.String("location", "", "render a location of a location file: `file.json[#name]`, the last one of the file when no name is given (the center, zoom, iterations, formula and palette flags take precedence)")
+	supersampling := flags.Int("supersampling", 1, fmt.Sprintf("anti-aliasing, every pixel is the average of NxN samples, N up to %d: `N` (the histogram coloring keeps 12 bytes per sample in memory)", fractal.MaxSupersampling))
+	slaves := flags.String("slaves", "", "cluster node slaves IP's (or IP:port) separated by comas")
+	slaveTimeout := flags.Duration("slave-timeout", fractal.DefaultSlaveTimeout, "max time to wait for a tile calculated by a slave node (deep zooms are slow)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	centerX, centerY, err := parseCenter(*center)
+	if err != nil {
+		return err
+	}
+
+	coloringMode, err := fractal.ParseColoringMode(*coloring)
+	if err != nil {
+		return err
+	}
+
+	deepZoomMode, err := fractal.ParseDeepZoomMode(*deepZoom)
+	if err != nil {
+		return err
+	}
+
+	iteratedFormula, err := fractal.ParseFormula(*formula)
+	if err != nil {
+		return err
+	}
+
+	colorPalette, err := loadPalette(*palette, *paletteOffset, *paletteScale)
+	if err != nil {
+		return err
+	}
+
+	if *width <= 0 || *height <= 0 {
+		return fmt.Errorf("invalid image size %dx%d", *width, *height)
+	}
+
+	if *maxIterations <= 0 || math.IsNaN(*maxIterations) || math.IsInf(*maxIterations, 0) {
+		return fmt.Errorf("invalid max iterations %v", *maxIterations)
+	}
+
+	if *bailout < 2 {
+		return fmt.Errorf("invalid bailout radius %v, it must be at least 2", *bailout)
+	}
+
+	if *supersampling < 1 || *supersampling > fractal.MaxSupersampling {
+		return fmt.Errorf("invalid supersampling %d, it must be between 1 and %d", *supersampling, fractal.MaxSupersampling)
+	}
+
+	magnification, err := parseZoom(*zoom)
+	if err != nil {
+		return err
+	}
+
+	viewport := fractal.Viewport{
+		Width:               int32(*width),
+		Height:              int32(*height),
+		MagnificationFactor: magnification,
+		MaxIterations:       *maxIterations,
+		Coloring:            coloringMode,
+		BailoutRadius:       *bailout,
+		DeepZoom:            deepZoomMode,
+		Formula:             iteratedFormula,
+		Palette:             colorPalette,
+		Supersampling:       int32(*supersampling),
+	}
+	viewport.SetCenter(centerX, centerY)
+
+	if len(*location) > 0 {
+		l, err := loadLocation(*location)
+		if err != nil {
+			return err
+		}
+
+		explicit := explicitFlags(flags)
+		if explicit["center"] {
+			l.CenterX, l.CenterY = centerX, centerY
+		}
+		if explicit["zoom"] {
+			l.Magnification = viewport.MagnificationFactor
+		}
+		if explicit["iterations"] {
+			l.MaxIterations = viewport.MaxIterations
+		}
+		if explicit["formula"] {
+			l.Formula = viewport.Formula
+		}
+		if explicit["palette"] || explicit["palette-offset"] || explicit["palette-scale"] {
+			l.Palette = viewport.Palette
+		}
+		viewport.GoTo(l)
+	}
+
+	var slavesIPs []string
+	if len(*slaves) > 0 {
+		slavesIPs = strings.Split(*slaves, ",")
+	}
+
+	cluster, err := fractal.NewCluster(MAX_THREADS, slavesIPs, SLAVE_PORT)
+	if err != nil {
+		return err
+	}
+
+	cluster.SlaveTimeout = *slaveTimeout
+	// A single frame is never recolored
+	cluster.KeepIterations = false
+
+	img := image.NewRGBA(viewport.Bounds().Rect())
+	if err := cluster.Render(viewport, img); err != nil {
+		return err
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return err
+	}
+
+	if err := png.Encode(file, img); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if viewport.UsesPerturbation() {
+		fmt.Printf("- Using perturbation with a reference orbit of %d bits\n", viewport.Precision())
+	} else if viewport.NeedsArbitraryPrecision() {
+		fmt.Printf("- Using arbitrary precision (%d bits)\n", viewport.Precision())
+	}
+	for i, state := range cluster.SlavesStates {
+		if !state.Healthy {
+			fmt.Printf("- Slave node %s failed, its region was calculated by other nodes: %v\n", cluster.SlaveAddress(int32(i)), state.LastError)
+		}
+	}
+	if cluster.SkippedIterations > 0 {
+		fmt.Printf("- Series approximation skipped %d iterations\n", cluster.SkippedIterations)
+	}
+	fmt.Printf("- Rendered %s in %s\n", *output, cluster.FrameProcessTime)
+	return nil
+}
+
+// parseZoom parses a magnification factor keeping all its digits, so zooms
+// beyond the float64 range can be rendered.
+func parseZoom(value string) (*big.Float, error) {
+	zoom, err := fractal.ParseFloat(strings.TrimSpace(value))
+	if err != nil {
+		return nil, fmt.Errorf("invalid zoom %q: %v", value, err)
+	}
+	if zoom.Sign() <= 0 || zoom.IsInf() {
+		return nil, fmt.Errorf("invalid zoom %q", value)
+	}
+	return zoom, nil
+}
+
+// parseCenter parses the coordinates of a point keeping all their digits, so
+// deep zoom locations can be rendered.
+func parseCenter(value string) (*big.Float, *big.Float, error) {
+	coordinates := strings.Split(value, ",")
+	if len(coordinates) != 2 {
+		return nil, nil, fmt.Errorf("invalid center %q, expected x,y", value)
+	}
+
+	x, err := fractal.ParseFloat(strings.TrimSpace(coordinates[0]))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid center %q: %v", value, err)
+	}
+
+	y, err := fractal.ParseFloat(strings.TrimSpace(coordinates[1]))
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid center %q: %v", value, err)
+	}
+
+	return x, y, nil
+}
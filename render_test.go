@@ -0,0 +1,91 @@
+package main
+
+import (
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "render")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	output := filepath.Join(dir, "frame.png")
+	if err := runRenderCommand([]string{"--width=24", "--height=16", "--zoom=5", "--iterations=50", "--coloring=smooth", "--output=" + output}); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := img.Bounds().Size(); size.X != 24 || size.Y != 16 {
+		t.Errorf("image of %dx%d pixels, want 24x16", size.X, size.Y)
+	}
+	// The center of the default view belongs to the set, the corners don't
+	if r, g, b, _ := img.At(12, 8).RGBA(); r != 0 || g != 0 || b != 0 {
+		t.Errorf("center of the set not black")
+	}
+	if r, g, b, _ := img.At(0, 0).RGBA(); r == 0 && g == 0 && b == 0 {
+		t.Errorf("corner outside the set black")
+	}
+}
+
+func TestRenderCommandErrors(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--unknown"}, "not defined"},
+		{[]string{"--width=0"}, "invalid image size"},
+		{[]string{"--height=-5"}, "invalid image size"},
+		{[]string{"--iterations=0"}, "invalid max iterations"},
+		{[]string{"--iterations=-5", "--coloring=histogram"}, "invalid max iterations"},
+		{[]string{"--iterations=NaN"}, "invalid max iterations"},
+		{[]string{"--zoom=0"}, "invalid zoom"},
+		{[]string{"--zoom=x"}, "invalid zoom"},
+		{[]string{"--center=1"}, "invalid center"},
+		{[]string{"--center=1,2,3"}, "invalid center"},
+		{[]string{"--center=a,0"}, "invalid center"},
+		{[]string{"--center=0,"}, "invalid center"},
+	}
+
+	for _, test := range tests {
+		// The output is never written, every argument is rejected before
+		args := append(test.args, "--output="+filepath.Join(os.TempDir(), "never-written.png"))
+		err := runRenderCommand(args)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("runRenderCommand(%q) = %v, want an error containing %q", test.args, err, test.want)
+		}
+	}
+}
+
+func TestParseCenter(t *testing.T) {
+	x, y, err := parseCenter("-0.743643887037158704752191506114774, 0.131825904205311970493132056385139")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := x.Text('g', 33); got != "-0.743643887037158704752191506114774" {
+		t.Errorf("x = %s, digits lost", got)
+	}
+	if got := y.Text('g', 33); got != "0.131825904205311970493132056385139" {
+		t.Errorf("y = %s, digits lost", got)
+	}
+
+	for _, center := range []string{"", "1", "1,2,3", "a,0", "0,b", ",", "0;1"} {
+		if _, _, err := parseCenter(center); err == nil {
+			t.Errorf("parseCenter(%q) accepted", center)
+		}
+	}
+}